@@ -0,0 +1,188 @@
+package scope_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+
+	"github.com/alphaflow/scope"
+	"github.com/alphaflow/scope/util"
+)
+
+type TestDatedObject struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	Number     float64   `json:"num" db:"num"`
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at"`
+}
+
+func (t TestDatedObject) TableName() string {
+	return "dated_objects"
+}
+
+func (ss *ScopesSuite) createDatedObjects(numbersByDate map[string][]float64) {
+	for date, numbers := range numbersByDate {
+		occurredAt, err := time.Parse("2006-01-02", date)
+		ss.NoError(err)
+
+		for _, number := range numbers {
+			testObject := &TestDatedObject{ID: uuid.Must(uuid.NewV4()), Number: number, OccurredAt: occurredAt}
+			err := ss.DB.Create(testObject)
+			ss.NoError(err)
+		}
+	}
+}
+
+func (ss *ScopesSuite) TestGetAggregationsFromParams_Comparison_PreviousPeriod() {
+	ss.createDatedObjects(map[string][]float64{
+		"2021-01-15": {1, 2},
+		"2021-02-15": {3, 4, 5},
+		"2021-03-15": {100},
+	})
+
+	params := map[string][]string{
+		"aggregation_column":            {"id|num"},
+		"aggregation_type":              {"count|sum"},
+		"aggregation_comparison_column": {"occurred_at"},
+		"aggregation_comparison_start":  {"2021-02-01"},
+		"aggregation_comparison_end":    {"2021-03-01"},
+	}
+
+	aggregation, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestDatedObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(scope.ComparedAggregation{Current: 3, Previous: 2, Change: 1, PercentChange: float64(50)}, util.GetFieldByName(aggregation, "Result0").Interface())
+	ss.Equal(scope.ComparedAggregation{Current: float64(12), Previous: float64(3), Change: float64(9), PercentChange: float64(300)}, util.GetFieldByName(aggregation, "Result1").Interface())
+
+	jsn, err := json.Marshal(aggregation)
+	ss.NoError(err)
+	ss.Equal(`{"count_id":{"current":3,"previous":2,"change":1,"percent_change":50},"sum_num":{"current":12,"previous":3,"change":9,"percent_change":300}}`, string(jsn))
+}
+
+func (ss *ScopesSuite) TestGetAggregationsFromParams_Comparison_PreviousYear() {
+	ss.createDatedObjects(map[string][]float64{
+		"2020-02-15": {4},
+		"2021-01-15": {100},
+		"2021-02-15": {3},
+	})
+
+	params := map[string][]string{
+		"aggregation_column":            {"num"},
+		"aggregation_type":              {"sum"},
+		"aggregation_comparison_column": {"occurred_at"},
+		"aggregation_comparison_type":   {"previous_year"},
+		"aggregation_comparison_start":  {"2021-02-01"},
+		"aggregation_comparison_end":    {"2021-03-01"},
+	}
+
+	aggregation, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestDatedObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(scope.ComparedAggregation{Current: float64(3), Previous: float64(4), Change: float64(-1), PercentChange: float64(-25)}, util.GetFieldByName(aggregation, "Result0").Interface())
+}
+
+func (ss *ScopesSuite) TestGetAggregationsFromParams_Comparison_Custom() {
+	ss.createDatedObjects(map[string][]float64{
+		"2020-11-15": {4},
+		"2021-01-15": {100},
+		"2021-02-15": {3},
+	})
+
+	params := map[string][]string{
+		"aggregation_column":            {"num"},
+		"aggregation_type":              {"sum"},
+		"aggregation_comparison_column": {"occurred_at"},
+		"aggregation_comparison_type":   {"custom"},
+		"aggregation_comparison_offset": {"3 months"},
+		"aggregation_comparison_start":  {"2021-02-01"},
+		"aggregation_comparison_end":    {"2021-03-01"},
+	}
+
+	aggregation, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestDatedObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(scope.ComparedAggregation{Current: float64(3), Previous: float64(4), Change: float64(-1), PercentChange: float64(-25)}, util.GetFieldByName(aggregation, "Result0").Interface())
+}
+
+func (ss *ScopesSuite) TestGetComparisonAggregations_emptyPeriod() {
+	ss.createDatedObjects(map[string][]float64{
+		"2021-02-15": {3},
+	})
+
+	comparison := scope.AggregationComparison{
+		ColumnName: "occurred_at",
+		Start:      time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		Type:       scope.AggregationComparisonTypePreviousPeriod,
+	}
+
+	aggregations := scope.Aggregations{scope.StandardAggregations[scope.StandardAggregationsTypeSum], scope.StandardAggregations[scope.StandardAggregationsTypeCount]}
+	aggregation, err := scope.GetComparisonAggregations(context.Background(), ss.DB, &[]TestDatedObject{}, []string{"num", "num"}, nil, aggregations, comparison)
+	ss.NoError(err)
+	ss.Equal(scope.ComparedAggregation{Current: float64(3)}, util.GetFieldByName(aggregation, "Result0").Interface())
+	ss.Equal(scope.ComparedAggregation{Current: 1, Previous: 0, Change: 1}, util.GetFieldByName(aggregation, "Result1").Interface())
+}
+
+func (ss *ScopesSuite) TestGetComparisonAggregations_scoped() {
+	ss.createDatedObjects(map[string][]float64{
+		"2021-01-15": {1, 2},
+		"2021-02-15": {3, 4},
+	})
+
+	comparison := scope.AggregationComparison{
+		ColumnName: "occurred_at",
+		Start:      time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		Type:       scope.AggregationComparisonTypePreviousPeriod,
+	}
+
+	sc := scope.NewCollection(ss.DB)
+	sc.Push(scope.ForNotNull("num"), func(q *pop.Query) *pop.Query {
+		return q.Where("num > ?", 1)
+	})
+
+	aggregations := scope.Aggregations{scope.StandardAggregations[scope.StandardAggregationsTypeSum]}
+	aggregation, err := scope.GetComparisonAggregations(context.Background(), ss.DB, &[]TestDatedObject{}, []string{"num"}, sc, aggregations, comparison)
+	ss.NoError(err)
+	ss.Equal(scope.ComparedAggregation{Current: float64(7), Previous: float64(2), Change: float64(5), PercentChange: float64(250)}, util.GetFieldByName(aggregation, "Result0").Interface())
+}
+
+func (ss *ScopesSuite) TestGetAggregationsFromParams_Comparison_invalid() {
+	testCases := []map[string][]string{
+		{
+			"aggregation_column":            {"num"},
+			"aggregation_type":              {"sum"},
+			"aggregation_comparison_column": {"occurred_at"},
+			"aggregation_comparison_start":  {"2021-03-01"},
+			"aggregation_comparison_end":    {"2021-02-01"},
+		},
+		{
+			"aggregation_column":            {"num"},
+			"aggregation_type":              {"sum"},
+			"aggregation_comparison_column": {"occurred_at"},
+			"aggregation_comparison_start":  {"yesterday"},
+			"aggregation_comparison_end":    {"2021-02-01"},
+		},
+		{
+			"aggregation_column":            {"num"},
+			"aggregation_type":              {"sum"},
+			"aggregation_comparison_column": {"occurred_at"},
+			"aggregation_comparison_type":   {"custom"},
+			"aggregation_comparison_offset": {"1 fortnight"},
+			"aggregation_comparison_start":  {"2021-02-01"},
+			"aggregation_comparison_end":    {"2021-03-01"},
+		},
+		{
+			"aggregation_column":            {"num"},
+			"aggregation_type":              {"sum"},
+			"aggregation_comparison_column": {"not_in_db"},
+			"aggregation_comparison_start":  {"2021-02-01"},
+			"aggregation_comparison_end":    {"2021-03-01"},
+		},
+	}
+
+	for _, params := range testCases {
+		_, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestDatedObject{}, url.Values(params), nil)
+		ss.Error(err)
+	}
+}
@@ -14,13 +14,41 @@ import (
 	"github.com/alphaflow/scope/util"
 )
 
+// Aggregation represents a SQL aggregate that can be applied to a CustomColumn.  For example:
+//
+// Given an object with db columns 'rate' and 'principal', in order to aggregate the principal weighted average of
+// 'rate' you would implement 'CustomAggregatable' and provide the Aggregation:
+//
+//	{
+//		Name:       "weighted_avg",
+//		Statement:  "SUM({column} * loans.principal) / NULLIF(SUM(loans.principal), 0)",
+//		ResultType: reflect.TypeOf(float64(0)),
+//	}
+//
+// Statement is either the name of an aggregate function (ex. "SUM"), which is applied to the aggregated column, or an
+// expression containing AggregationColumnPlaceholder, which is replaced by the aggregated column.
+// ResultType is the type of the value returned by statement.  When nil, the ResultType of the column is used.
+// Args are bound to any '?' placeholders in Statement.
+// Modifier optionally transforms the aggregated value of each group, see AggregationModifier.
 type Aggregation struct {
 	Name       string
 	Statement  string
 	ResultType reflect.Type
+	Args       []interface{}
+	Modifier   *AggregationModifier
 }
 
 type Aggregations []Aggregation
+
+// AggregationColumnPlaceholder is replaced by the aggregated column's statement within an Aggregation's Statement.
+const AggregationColumnPlaceholder = "{column}"
+
+// CustomAggregatable allows a model to provide aggregations beyond the StandardAggregations.  Custom aggregations are
+// requested by name as an `aggregation_type`, in the same way as the standard aggregations.
+type CustomAggregatable interface {
+	GetCustomAggregations(ctx context.Context) Aggregations
+}
+
 type StandardAggregationsType string
 
 const (
@@ -31,6 +59,16 @@ const (
 	StandardAggregationsTypeMin   StandardAggregationsType = "MIN"
 )
 
+// standardAggregationsTypes are the types of the StandardAggregations, in the order they are returned by
+// GetAllAggregations.
+var standardAggregationsTypes = []StandardAggregationsType{
+	StandardAggregationsTypeCount,
+	StandardAggregationsTypeSum,
+	StandardAggregationsTypeAvg,
+	StandardAggregationsTypeMax,
+	StandardAggregationsTypeMin,
+}
+
 var StandardAggregations = map[StandardAggregationsType]Aggregation{
 	StandardAggregationsTypeCount: {
 		Name:       string(StandardAggregationsTypeCount),
@@ -59,6 +97,60 @@ var StandardAggregations = map[StandardAggregationsType]Aggregation{
 	},
 }
 
+// AggregationModifier transforms the aggregated value of each group of a grouped aggregation using a SQL window
+// function over the grouped result.  Groups are ordered by the grouper column.
+//
+// Statement is an expression where AggregationPlaceholder is replaced by the aggregation of each group, and
+// AggregationGrouperPlaceholder is replaced by the grouper column.
+// ResultType is the type of the value returned by statement.  When nil, the ResultType of the aggregation is used.
+type AggregationModifier struct {
+	Name       string
+	Statement  string
+	ResultType reflect.Type
+}
+
+// AggregationPlaceholder is replaced by the aggregation of each group within an AggregationModifier's Statement.
+const AggregationPlaceholder = "{aggregation}"
+
+// AggregationGrouperPlaceholder is replaced by the grouper column within an AggregationModifier's Statement.
+const AggregationGrouperPlaceholder = "{grouper}"
+
+type StandardAggregationModifiersType string
+
+const (
+	StandardAggregationModifiersTypeRunningTotal      StandardAggregationModifiersType = "RUNNING_TOTAL"
+	StandardAggregationModifiersTypeRank              StandardAggregationModifiersType = "RANK"
+	StandardAggregationModifiersTypePercentOfTotal    StandardAggregationModifiersType = "PERCENT_OF_TOTAL"
+	StandardAggregationModifiersTypeDeltaFromPrevious StandardAggregationModifiersType = "DELTA_FROM_PREVIOUS"
+)
+
+var StandardAggregationModifiers = map[StandardAggregationModifiersType]AggregationModifier{
+	// The cumulative sum of this group and all groups before it.
+	StandardAggregationModifiersTypeRunningTotal: {
+		Name:       string(StandardAggregationModifiersTypeRunningTotal),
+		Statement:  "SUM({aggregation}) OVER (ORDER BY {grouper} ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)",
+		ResultType: nil,
+	},
+	// The rank of this group, where the group with the largest aggregation is ranked 1.
+	StandardAggregationModifiersTypeRank: {
+		Name:       string(StandardAggregationModifiersTypeRank),
+		Statement:  "RANK() OVER (ORDER BY {aggregation} DESC)",
+		ResultType: reflect.TypeOf(0),
+	},
+	// The percentage of the total of all groups that belongs to this group.  0 when the total is 0.
+	StandardAggregationModifiersTypePercentOfTotal: {
+		Name:       string(StandardAggregationModifiersTypePercentOfTotal),
+		Statement:  "COALESCE({aggregation} * 100.0 / NULLIF(SUM({aggregation}) OVER (), 0), 0)",
+		ResultType: reflect.TypeOf(float64(0)),
+	},
+	// The difference between this group and the group before it.  The first group is compared to 0.
+	StandardAggregationModifiersTypeDeltaFromPrevious: {
+		Name:       string(StandardAggregationModifiersTypeDeltaFromPrevious),
+		Statement:  "{aggregation} - COALESCE(LAG({aggregation}) OVER (ORDER BY {grouper}), 0)",
+		ResultType: nil,
+	},
+}
+
 // aggregationsQueryResult is a struct with an interface column.  The type of interface is swapped out using
 // reflection in getCustomAggregations in order to be able to scan DB values into any type as needed.
 type aggregationsQueryResult struct {
@@ -74,7 +166,9 @@ type groupedAggregationsQueryResult struct {
 
 // GetAggregationsFromParams aggregates a modelsPtr based on params, restricting by the scope collection scopes.
 func GetAggregationsFromParams(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, params buffalo.ParamValues, scopes *Collection) (interface{}, error) {
-	filterSeparator := getFilterSeparator(params)
+	ctx, params = withParamsConfig(ctx, params, scopes)
+
+	filterSeparator := getFilterSeparator(ctx, params)
 
 	columns := make([]string, 0)
 	if !util.IsBlank(params.Get("aggregation_column")) {
@@ -87,17 +181,25 @@ func GetAggregationsFromParams(ctx context.Context, tx *pop.Connection, modelsPt
 
 	if len(columns) != len(types) {
 		// We must have the same number of all aggregation params.
-		return nil, errors.New("missing or mismatched aggregation parameters")
+		return nil, newParamError(ParamErrorCodeMismatchedParams, "", "", "missing or mismatched aggregation parameters")
 	}
 
-	aggregations := Aggregations{}
-	for _, aggregationType := range types {
-		aggregation, ok := StandardAggregations[StandardAggregationsType(strings.ToUpper(aggregationType))]
-		if !ok {
-			return nil, errors.New("unknown aggregation type")
+	if limits := LimitsFromContext(ctx); exceeds(len(columns), limits.MaxAggregations) {
+		return nil, newParamError(ParamErrorCodeLimitExceeded, "aggregation_column", "", "too many aggregations: more than %v", limits.MaxAggregations)
+	}
+
+	aggregations, err := getAggregationsForTypes(ctx, modelsPtr, types)
+	if err != nil {
+		return nil, err
+	}
+
+	if !util.IsBlank(params.Get("aggregation_comparison_column")) {
+		comparison, err := getAggregationComparisonFromParams(ctx, params)
+		if err != nil {
+			return nil, err
 		}
 
-		aggregations = append(aggregations, aggregation)
+		return GetComparisonAggregations(ctx, tx, modelsPtr, columns, scopes, aggregations, comparison)
 	}
 
 	aggregationResult, err := GetAggregations(ctx, tx, modelsPtr, columns, scopes, aggregations)
@@ -110,7 +212,9 @@ func GetAggregationsFromParams(ctx context.Context, tx *pop.Connection, modelsPt
 
 // GetGroupedAggregationsFromParams groups and aggregates a modelsPtr based on params, restricting by the scope collection scopes.
 func GetGroupedAggregationsFromParams(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, params buffalo.ParamValues, scopes *Collection) ([]interface{}, error) {
-	filterSeparator := getFilterSeparator(params)
+	ctx, params = withParamsConfig(ctx, params, scopes)
+
+	filterSeparator := getFilterSeparator(ctx, params)
 
 	columns := make([]string, 0)
 	if !util.IsBlank(params.Get("aggregation_column")) {
@@ -123,17 +227,39 @@ func GetGroupedAggregationsFromParams(ctx context.Context, tx *pop.Connection, m
 
 	if len(columns) != len(types) {
 		// We must have the same number of all aggregation params, and only 1 grouper.
-		return nil, errors.New("missing or mismatched aggregation parameters")
+		return nil, newParamError(ParamErrorCodeMismatchedParams, "", "", "missing or mismatched aggregation parameters")
 	}
 
-	aggregations := Aggregations{}
-	for _, aggregationType := range types {
-		aggregation, ok := StandardAggregations[StandardAggregationsType(strings.ToUpper(aggregationType))]
+	if limits := LimitsFromContext(ctx); exceeds(len(columns), limits.MaxAggregations) {
+		return nil, newParamError(ParamErrorCodeLimitExceeded, "aggregation_column", "", "too many aggregations: more than %v", limits.MaxAggregations)
+	}
+
+	aggregations, err := getAggregationsForTypes(ctx, modelsPtr, types)
+	if err != nil {
+		return nil, err
+	}
+
+	modifiers := make([]string, 0)
+	if !util.IsBlank(params.Get("aggregation_modifier")) {
+		modifiers = strings.Split(params.Get("aggregation_modifier"), filterSeparator)
+	}
+
+	if len(modifiers) > 0 && len(modifiers) != len(types) {
+		// Modifiers are optional, but if any are specified there must be one for each aggregation.
+		return nil, newParamError(ParamErrorCodeMismatchedParams, "", "", "missing or mismatched aggregation parameters")
+	}
+
+	for i, modifierType := range modifiers {
+		if util.IsBlank(modifierType) {
+			continue
+		}
+
+		modifier, ok := StandardAggregationModifiers[StandardAggregationModifiersType(strings.ToUpper(modifierType))]
 		if !ok {
-			return nil, errors.New("unknown aggregation type")
+			return nil, newClauseError(ParamErrorCodeInvalidType, "aggregation_modifier", i, modifierType, "unknown aggregation modifier")
 		}
 
-		aggregations = append(aggregations, aggregation)
+		aggregations[i].Modifier = &modifier
 	}
 
 	aggregationGrouperColumn := params.Get("aggregation_grouper_column")
@@ -152,6 +278,8 @@ func GetGroupedAggregationsFromParams(ctx context.Context, tx *pop.Connection, m
 // `columnName` is either a CustomColumn returned by the CustomFilterable interface, or a field specified by the json
 // tag.  This is the same as the acceptable values for `filter_columns` in ForFiltersFromParams.
 func GetAggregations(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, columnNames []string, scopes *Collection, aggregations Aggregations) (interface{}, error) {
+	ctx = withCollectionConfig(ctx, scopes)
+
 	v := reflect.ValueOf(modelsPtr)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
 		return nil, errors.New("pointer to slice expected")
@@ -160,30 +288,32 @@ func GetAggregations(ctx context.Context, tx *pop.Connection, modelsPtr interfac
 	models := v.Elem()
 	modelPtr := reflect.New(models.Type().Elem()).Interface()
 
+	// Columns are generated for the dialect of the connection.
+	ctx = WithDialect(ctx, getDialect(tx))
 	filterColumns, err := GetAllFilterColumns(ctx, modelPtr)
 	if err != nil {
 		return nil, err
 	}
 
 	customColumns := CustomColumns{}
-	for _, columnName := range columnNames {
+	for i, columnName := range columnNames {
 		var column *CustomColumn
-		for i, filterColumn := range filterColumns {
+		for j, filterColumn := range filterColumns {
 			if columnName == filterColumn.Name {
-				column = &filterColumns[i]
+				column = &filterColumns[j]
 				break
 			}
 		}
 
 		if column == nil {
-			return nil, errors.Errorf("invalid filter field: %v", columnName)
+			return nil, newClauseError(ParamErrorCodeInvalidField, "aggregation_column", i, columnName, "invalid filter field: %v", columnName)
 		}
 
 		customColumns = append(customColumns, *column)
 	}
 
 	tableName := (&pop.Model{Value: modelPtr}).TableName()
-	return getCustomAggregations(tx, tableName, customColumns, scopes, aggregations)
+	return getCustomAggregations(ctx, tx, tableName, customColumns, scopes, aggregations)
 }
 
 // GetGroupedAggregations returns the aggregated value for column `columnName` of modelsPtr, grouped by `grouperName` of
@@ -192,6 +322,8 @@ func GetAggregations(ctx context.Context, tx *pop.Connection, modelsPtr interfac
 // `columnName` is either a CustomColumn returned by the CustomFilterable interface, or a field specified by the json
 // tag.  This is the same as the acceptable values for `filter_columns` in ForFiltersFromParams.
 func GetGroupedAggregations(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, columnNames []string, grouperName string, scopes *Collection, aggregations Aggregations) ([]interface{}, error) {
+	ctx = withCollectionConfig(ctx, scopes)
+
 	v := reflect.ValueOf(modelsPtr)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
 		return nil, errors.New("pointer to slice expected")
@@ -200,6 +332,8 @@ func GetGroupedAggregations(ctx context.Context, tx *pop.Connection, modelsPtr i
 	models := v.Elem()
 	modelPtr := reflect.New(models.Type().Elem()).Interface()
 
+	// Columns are generated for the dialect of the connection.
+	ctx = WithDialect(ctx, getDialect(tx))
 	filterColumns, err := GetAllFilterColumns(ctx, modelPtr)
 	if err != nil {
 		return nil, err
@@ -207,15 +341,15 @@ func GetGroupedAggregations(ctx context.Context, tx *pop.Connection, modelsPtr i
 
 	customColumns := CustomColumns{}
 	var grouper *CustomColumn
-	for _, columnName := range columnNames {
+	for i, columnName := range columnNames {
 		var column *CustomColumn
-		for i, filterColumn := range filterColumns {
+		for j, filterColumn := range filterColumns {
 			if columnName == filterColumn.Name {
-				column = &filterColumns[i]
+				column = &filterColumns[j]
 			}
 
 			if grouper == nil && grouperName == filterColumn.Name {
-				grouper = &filterColumns[i]
+				grouper = &filterColumns[j]
 			}
 
 			if grouper != nil && column != nil {
@@ -224,17 +358,163 @@ func GetGroupedAggregations(ctx context.Context, tx *pop.Connection, modelsPtr i
 		}
 
 		if column == nil {
-			return nil, errors.Errorf("invalid filter field: %v", columnName)
+			return nil, newClauseError(ParamErrorCodeInvalidField, "aggregation_column", i, columnName, "invalid filter field: %v", columnName)
 		}
 
 		customColumns = append(customColumns, *column)
 	}
 	if grouper == nil {
-		return nil, errors.Errorf("invalid filter field: %v", grouperName)
+		return nil, newParamError(ParamErrorCodeInvalidField, "aggregation_grouper_column", grouperName, "invalid filter field: %v", grouperName)
 	}
 
 	tableName := (&pop.Model{Value: modelPtr}).TableName()
-	return getCustomGroupedAggregations(tx, tableName, customColumns, *grouper, scopes, aggregations)
+	return getCustomGroupedAggregations(ctx, tx, tableName, customColumns, *grouper, scopes, aggregations)
+}
+
+// GetAllAggregations is a utility in order to automatically get a list of all aggregations that can be requested for
+// the referenced model.  This includes the StandardAggregations, and any aggregations returned by the CustomAggregatable
+// interface, in that order.  Custom aggregations override standard aggregations of the same name.
+func GetAllAggregations(ctx context.Context, modelPtr interface{}) (Aggregations, error) {
+	v := reflect.ValueOf(modelPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("pointer to struct expected")
+	}
+
+	model := v.Elem().Interface()
+
+	customAggregations := Aggregations{}
+	if customAggregatable, ok := model.(CustomAggregatable); ok {
+		customAggregations = customAggregatable.GetCustomAggregations(ctx)
+	}
+
+	allAggregations := Aggregations{}
+	for _, aggregationType := range standardAggregationsTypes {
+		aggregation, ok := StandardAggregations[aggregationType]
+		if !ok {
+			continue
+		}
+
+		overridden := false
+		for _, customAggregation := range customAggregations {
+			if strings.EqualFold(customAggregation.Name, string(aggregationType)) {
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			allAggregations = append(allAggregations, aggregation)
+		}
+	}
+
+	return append(allAggregations, customAggregations...), nil
+}
+
+// getAggregationsForTypes resolves each of the requested aggregation `types` by name against the aggregations
+// available to the model of modelsPtr.  Names are matched case insensitively.
+func getAggregationsForTypes(ctx context.Context, modelsPtr interface{}, types []string) (Aggregations, error) {
+	v := reflect.ValueOf(modelsPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return nil, errors.New("pointer to slice expected")
+	}
+
+	modelPtr := reflect.New(v.Elem().Type().Elem()).Interface()
+
+	allAggregations, err := GetAllAggregations(ctx, modelPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregations := Aggregations{}
+	for i, aggregationType := range types {
+		var aggregation *Aggregation
+		for j := range allAggregations {
+			if strings.EqualFold(allAggregations[j].Name, aggregationType) {
+				aggregation = &allAggregations[j]
+				break
+			}
+		}
+
+		if aggregation == nil {
+			return nil, newClauseError(ParamErrorCodeInvalidType, "aggregation_type", i, aggregationType, "unknown aggregation type")
+		}
+
+		aggregations = append(aggregations, *aggregation)
+	}
+
+	return aggregations, nil
+}
+
+// statementFor returns the SQL expression applying this aggregation to `customColumn`, along with the args for that
+// expression in the order their placeholders appear.  `columnArgs` are the args bound by the column statement itself,
+// and are repeated for every occurrence of the column within the expression.
+func (a Aggregation) statementFor(customColumn CustomColumn, columnArgs ...interface{}) (string, []interface{}) {
+	args := make([]interface{}, 0)
+	if !strings.Contains(a.Statement, AggregationColumnPlaceholder) {
+		args = append(args, a.Args...)
+		return fmt.Sprintf("%v(%v)", a.Statement, customColumn.Statement), append(args, columnArgs...)
+	}
+
+	remainingArgs := a.Args
+	segments := strings.Split(a.Statement, AggregationColumnPlaceholder)
+	for i, segment := range segments {
+		segmentArgCount := strings.Count(segment, "?")
+		if segmentArgCount > len(remainingArgs) {
+			segmentArgCount = len(remainingArgs)
+		}
+
+		args = append(args, remainingArgs[:segmentArgCount]...)
+		remainingArgs = remainingArgs[segmentArgCount:]
+
+		if i < len(segments)-1 {
+			args = append(args, columnArgs...)
+		}
+	}
+
+	return strings.Join(segments, customColumn.Statement), append(args, remainingArgs...)
+}
+
+// statementFor returns the SQL expression applying this modifier to `aggregationStatement`, grouped by `groupColumn`,
+// along with the args for that expression.  `aggregationArgs` are repeated for every occurrence of the aggregation
+// within the expression.
+func (m AggregationModifier) statementFor(aggregationStatement string, aggregationArgs []interface{}, groupColumn CustomColumn) (string, []interface{}) {
+	args := make([]interface{}, 0)
+	for i := 0; i < strings.Count(m.Statement, AggregationPlaceholder); i++ {
+		args = append(args, aggregationArgs...)
+	}
+
+	statement := strings.ReplaceAll(m.Statement, AggregationGrouperPlaceholder, groupColumn.Statement)
+	return strings.ReplaceAll(statement, AggregationPlaceholder, aggregationStatement), args
+}
+
+// numberPlaceholders replaces each '?' placeholder in `sql` with the placeholder of the dialect, numbered starting after
+// the `offset` placeholders already bound by the scopes.  The scope clauses are already numbered by the time they are
+// combined with the aggregation statements, so their args are bound by bindPlaceholderArgs.
+func numberPlaceholders(sql string, offset int, dialect Dialect) string {
+	var sb strings.Builder
+	for _, r := range sql {
+		if r == '?' {
+			offset++
+			sb.WriteString(dialect.Placeholder(offset))
+			continue
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// bindPlaceholderArgs returns the args of a query in which statements numbered by numberPlaceholders, with `args`,
+// precede the scope clauses, with `scopeQueryArgs`.  Numbered placeholders are bound by their numbers, so the scope args
+// come first, while positional placeholders are bound in the order that they appear in the query.
+func bindPlaceholderArgs(dialect Dialect, scopeQueryArgs, args []interface{}) []interface{} {
+	boundArgs := make([]interface{}, 0, len(scopeQueryArgs)+len(args))
+	if dialect.Placeholder(1) == dialect.Placeholder(2) {
+		return append(append(boundArgs, args...), scopeQueryArgs...)
+	}
+
+	return append(append(boundArgs, scopeQueryArgs...), args...)
 }
 
 // getCustomAggregations returns the aggregated value for column for the provided `customColumn` from the table `tableName`,
@@ -242,18 +522,23 @@ func GetGroupedAggregations(ctx context.Context, tx *pop.Connection, modelsPtr i
 //
 // In order to do this, we must build a custom struct with the correct ResultType for customColumn.  We then build a
 // scoped GROUP BY query to retrieve all values for customColumn into that struct.
-func getCustomAggregations(tx *pop.Connection, tableName string, customColumns CustomColumns, scopes *Collection, aggregations Aggregations) (interface{}, error) {
+func getCustomAggregations(ctx context.Context, tx *pop.Connection, tableName string, customColumns CustomColumns, scopes *Collection, aggregations Aggregations) (interface{}, error) {
 	type __stub__ struct{}
 	clauses := ""
 
 	structFields := make([]reflect.StructField, len(aggregations))
 	queryStubs := make([]string, len(aggregations))
 	aggregationScopes := NewCollection(tx)
+	aggregationArgs := make([]interface{}, 0)
 	jsonKeySet := make(map[string]bool)
 	for i, aggregation := range aggregations {
+		if aggregation.Modifier != nil {
+			return nil, newClauseError(ParamErrorCodeUnsupported, "aggregation_modifier", i, aggregation.Modifier.Name, "aggregation modifiers require an aggregation grouper")
+		}
+
 		jsonKey := fmt.Sprintf("%v_%v", strings.ToLower(aggregation.Name), customColumns[i].Name)
 		if _, ok := jsonKeySet[jsonKey]; ok {
-			return nil, errors.New("duplicate aggregation parameter")
+			return nil, newClauseError(ParamErrorCodeDuplicateParam, "aggregation_column", i, customColumns[i].Name, "duplicate aggregation parameter")
 		}
 
 		jsonKeySet[jsonKey] = true
@@ -280,10 +565,12 @@ func getCustomAggregations(tx *pop.Connection, tableName string, customColumns C
 			trailingComma = ""
 		}
 		structFields[i] = templateStructField
-		queryStubs[i] = fmt.Sprintf("%v(%v) AS result%v%v", aggregation.Statement, customColumns[i].Statement, i, trailingComma)
+		aggregationStatement, statementArgs := aggregation.statementFor(customColumns[i])
+		queryStubs[i] = fmt.Sprintf("%v AS result%v%v", aggregationStatement, i, trailingComma)
+		aggregationArgs = append(aggregationArgs, statementArgs...)
 
 		// We never return null as a filter option.
-		aggregationScopes.Push(ForNotNull(customColumns[i].Statement))
+		aggregationScopes.Push(ForNotNull(customColumns[i].Statement), ForJoins(customColumns[i].Joins...))
 	}
 
 	//templateStructFieldDBTag := templateStructField.Tag.Get("db")
@@ -302,8 +589,13 @@ func getCustomAggregations(tx *pop.Connection, tableName string, customColumns C
 	orderRegex := regexp.MustCompile(`ORDER\s+BY\s+\w+(\s+ASC|\s+DESC)?([\s,]*\w+(\s+ASC|\s+DESC)?)*`)
 	clauses = orderRegex.ReplaceAllString(clauses, "")
 
-	generatedStatement := fmt.Sprintf("SELECT %v FROM %v %v", strings.Join(queryStubs, " "), tableName, clauses)
-	err := tx.RawQuery(generatedStatement, scopeQueryArgs...).First(typedStructWithDBTag.Interface())
+	generatedStatement := fmt.Sprintf("SELECT %v FROM %v %v", numberPlaceholders(strings.Join(queryStubs, " "), len(scopeQueryArgs), getDialect(tx)), fromTable(getDialect(tx), tableName), clauses)
+	queryArgs := bindPlaceholderArgs(getDialect(tx), scopeQueryArgs, aggregationArgs)
+	if err := checkQueryCost(ctx, tx, generatedStatement, queryArgs...); err != nil {
+		return nil, err
+	}
+
+	err := SimilarityError(tx.RawQuery(generatedStatement, queryArgs...).First(typedStructWithDBTag.Interface()))
 	if err != nil {
 		return nil, err
 	}
@@ -316,7 +608,7 @@ func getCustomAggregations(tx *pop.Connection, tableName string, customColumns C
 //
 // In order to do this, we must build a custom struct with the correct ResultType for customColumn.  We then build a
 // scoped GROUP BY query to retrieve all values for customColumn into that struct.
-func getCustomGroupedAggregations(tx *pop.Connection, tableName string, customColumns CustomColumns, groupColumn CustomColumn, scopes *Collection, aggregations Aggregations) ([]interface{}, error) {
+func getCustomGroupedAggregations(ctx context.Context, tx *pop.Connection, tableName string, customColumns CustomColumns, groupColumn CustomColumn, scopes *Collection, aggregations Aggregations) ([]interface{}, error) {
 	type __stub__ struct{}
 	clauses := ""
 
@@ -332,11 +624,15 @@ func getCustomGroupedAggregations(tx *pop.Connection, tableName string, customCo
 
 	queryStubs := make([]string, len(aggregations))
 	aggregationScopes := NewCollection(tx)
+	aggregationArgs := make([]interface{}, 0)
 	jsonKeySet := make(map[string]bool)
 	for i, aggregation := range aggregations {
 		jsonKey := fmt.Sprintf("%v_%v", strings.ToLower(aggregation.Name), customColumns[i].Name)
+		if aggregation.Modifier != nil {
+			jsonKey = fmt.Sprintf("%v_%v", strings.ToLower(aggregation.Modifier.Name), jsonKey)
+		}
 		if _, ok := jsonKeySet[jsonKey]; ok {
-			return nil, errors.New("duplicate aggregation parameter")
+			return nil, newClauseError(ParamErrorCodeDuplicateParam, "aggregation_column", i, customColumns[i].Name, "duplicate aggregation parameter")
 		}
 
 		jsonKeySet[jsonKey] = true
@@ -358,18 +654,29 @@ func getCustomGroupedAggregations(tx *pop.Connection, tableName string, customCo
 			templateStructField.Type = customColumns[i].ResultType
 		}
 
+		if aggregation.Modifier != nil && aggregation.Modifier.ResultType != nil {
+			templateStructField.Type = aggregation.Modifier.ResultType
+		}
+
 		trailingComma := ","
 		if i == len(aggregations)-1 {
 			trailingComma = ""
 		}
 
 		structFields[i+1] = templateStructField
-		queryStubs[i] = fmt.Sprintf("%v(%v) AS result%v%v", aggregation.Statement, customColumns[i].Statement, i, trailingComma)
+		aggregationStatement, statementArgs := aggregation.statementFor(customColumns[i])
+		if aggregation.Modifier != nil {
+			aggregationStatement, statementArgs = aggregation.Modifier.statementFor(aggregationStatement, statementArgs, groupColumn)
+		}
+		queryStubs[i] = fmt.Sprintf("%v AS result%v%v", aggregationStatement, i, trailingComma)
+		aggregationArgs = append(aggregationArgs, statementArgs...)
 
 		// We never return null as a filter option.
-		aggregationScopes.Push(ForNotNull(customColumns[i].Statement))
+		aggregationScopes.Push(ForNotNull(customColumns[i].Statement), ForJoins(customColumns[i].Joins...))
 	}
 
+	aggregationScopes.Push(ForJoins(groupColumn.Joins...))
+
 	typedStructArrayPtrWithDBTag := reflect.New(reflect.SliceOf(reflect.StructOf(structFields)))
 
 	if scopes != nil && len(scopes.scopes) > 0 {
@@ -385,8 +692,13 @@ func getCustomGroupedAggregations(tx *pop.Connection, tableName string, customCo
 	orderRegex := regexp.MustCompile(`ORDER\s+BY\s+\w+(\s+ASC|\s+DESC)?([\s,]*\w+(\s+ASC|\s+DESC)?)*`)
 	clauses = orderRegex.ReplaceAllString(clauses, "")
 
-	generatedStatement := fmt.Sprintf("SELECT %v AS grouper, %v FROM %v %v GROUP BY %v", groupColumn.Statement, strings.Join(queryStubs, " "), tableName, clauses, groupColumn.Statement)
-	err := tx.RawQuery(generatedStatement, scopeQueryArgs...).All(typedStructArrayPtrWithDBTag.Interface())
+	generatedStatement := fmt.Sprintf("SELECT %v AS grouper, %v FROM %v %v GROUP BY %v ORDER BY %v", groupColumn.Statement, numberPlaceholders(strings.Join(queryStubs, " "), len(scopeQueryArgs), getDialect(tx)), fromTable(getDialect(tx), tableName), clauses, groupColumn.Statement, groupColumn.Statement)
+	queryArgs := bindPlaceholderArgs(getDialect(tx), scopeQueryArgs, aggregationArgs)
+	if err := checkQueryCost(ctx, tx, generatedStatement, queryArgs...); err != nil {
+		return nil, err
+	}
+
+	err := SimilarityError(tx.RawQuery(generatedStatement, queryArgs...).All(typedStructArrayPtrWithDBTag.Interface()))
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/url"
+	"reflect"
 
 	"github.com/gobuffalo/nulls"
 
@@ -582,3 +583,199 @@ func (ss *ScopesSuite) TestGetGroupedAggregations_Min() {
 		Result0 float64    "db:\"result0\" json:\"min_num\""
 	}{Grouper: nuid, Result0: 123}}, aggregation)
 }
+
+func (t TestObject) GetCustomAggregations(ctx context.Context) scope.Aggregations {
+	sumAbove := scope.Aggregation{
+		Name:       "sum_above",
+		Statement:  "COALESCE(SUM({column}) FILTER (WHERE objects.num > ?), 0)",
+		ResultType: reflect.TypeOf(float64(0)),
+		Args:       []interface{}{100},
+	}
+	doubleSum := scope.Aggregation{
+		Name:      "double_sum",
+		Statement: "2 * SUM({column})",
+	}
+	return scope.Aggregations{sumAbove, doubleSum}
+}
+
+func (ss *ScopesSuite) TestGetAllAggregations() {
+	aggregations, err := scope.GetAllAggregations(context.Background(), &TestObject{})
+	ss.NoError(err)
+
+	aggregationNames := make([]string, len(aggregations))
+	for i := range aggregations {
+		aggregationNames[i] = aggregations[i].Name
+	}
+
+	ss.Equal([]string{"COUNT", "SUM", "AVG", "MAX", "MIN", "sum_above", "double_sum"}, aggregationNames)
+}
+
+func (ss *ScopesSuite) TestGetAggregationsFromParams_Custom() {
+	testObject := &TestObject{Number: 50}
+	err := ss.DB.Create(testObject)
+	ss.NoError(err)
+
+	testObject2 := &TestObject{Number: 150}
+	err = ss.DB.Create(testObject2)
+	ss.NoError(err)
+
+	params := map[string][]string{
+		"aggregation_column": {"num|num"},
+		"aggregation_type":   {"SUM_ABOVE|double_sum"},
+	}
+
+	aggregation, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(float64(150), util.GetFieldByName(aggregation, "Result0").Interface())
+	ss.Equal(float64(400), util.GetFieldByName(aggregation, "Result1").Interface())
+
+	jsn, err := json.Marshal(aggregation)
+	ss.NoError(err)
+	ss.Equal(`{"sum_above_num":150,"double_sum_num":400}`, string(jsn))
+}
+
+func (ss *ScopesSuite) TestGetAggregationsFromParams_Custom_scoped() {
+	testObject := &TestObject{Number: 150}
+	err := ss.DB.Create(testObject)
+	ss.NoError(err)
+
+	testObject2 := &TestObject{Number: 250}
+	err = ss.DB.Create(testObject2)
+	ss.NoError(err)
+
+	params := map[string][]string{
+		"aggregation_column": {"num"},
+		"aggregation_type":   {"sum_above"},
+	}
+
+	sc := scope.NewCollection(ss.DB)
+	sc.Push(scope.ForID(testObject.ID.String()))
+
+	aggregation, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), sc)
+	ss.NoError(err)
+	ss.Equal(float64(150), util.GetFieldByName(aggregation, "Result0").Interface())
+}
+
+func (ss *ScopesSuite) TestGetAggregationsFromParams_Unknown() {
+	params := map[string][]string{
+		"aggregation_column": {"num"},
+		"aggregation_type":   {"median"},
+	}
+
+	_, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.Error(err)
+}
+
+func (ss *ScopesSuite) TestGetGroupedAggregationsFromParams_Custom() {
+	nuid := util.UuidMust()
+	testObject := &TestObject{Nuid: nuid, Number: 50}
+	err := ss.DB.Create(testObject)
+	ss.NoError(err)
+
+	testObject2 := &TestObject{Nuid: nuid, Number: 150}
+	err = ss.DB.Create(testObject2)
+	ss.NoError(err)
+
+	params := map[string][]string{
+		"aggregation_column":         {"num"},
+		"aggregation_grouper_column": {"null_id"},
+		"aggregation_type":           {"sum_above"},
+	}
+
+	aggregation, err := scope.GetGroupedAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal([]interface{}{struct {
+		Grouper nulls.UUID "db:\"grouper\""
+		Result0 float64    "db:\"result0\" json:\"sum_above_num\""
+	}{Grouper: nuid, Result0: 150}}, aggregation)
+}
+
+func (ss *ScopesSuite) TestGetGroupedAggregationsFromParams_RunningTotal() {
+	for _, number := range []float64{1, 1, 2, 3} {
+		testObject := &TestObject{Number: number}
+		err := ss.DB.Create(testObject)
+		ss.NoError(err)
+	}
+
+	params := map[string][]string{
+		"aggregation_column":         {"id"},
+		"aggregation_grouper_column": {"num"},
+		"aggregation_type":           {"count"},
+		"aggregation_modifier":       {"running_total"},
+	}
+
+	type runningTotalResult = struct {
+		Grouper float64 "db:\"grouper\""
+		Result0 int     "db:\"result0\" json:\"running_total_count_id\""
+	}
+
+	aggregation, err := scope.GetGroupedAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal([]interface{}{
+		runningTotalResult{Grouper: 1, Result0: 2},
+		runningTotalResult{Grouper: 2, Result0: 3},
+		runningTotalResult{Grouper: 3, Result0: 4},
+	}, aggregation)
+}
+
+func (ss *ScopesSuite) TestGetGroupedAggregationsFromParams_Modifiers() {
+	for _, number := range []float64{1, 1, 2, 3} {
+		testObject := &TestObject{Number: number}
+		err := ss.DB.Create(testObject)
+		ss.NoError(err)
+	}
+
+	params := map[string][]string{
+		"aggregation_column":         {"id|id|id|num"},
+		"aggregation_grouper_column": {"num"},
+		"aggregation_type":           {"count|count|count|sum"},
+		"aggregation_modifier":       {"|RANK|percent_of_total|delta_from_previous"},
+	}
+
+	type modifiersResult = struct {
+		Grouper float64 "db:\"grouper\""
+		Result0 int     "db:\"result0\" json:\"count_id\""
+		Result1 int     "db:\"result1\" json:\"rank_count_id\""
+		Result2 float64 "db:\"result2\" json:\"percent_of_total_count_id\""
+		Result3 float64 "db:\"result3\" json:\"delta_from_previous_sum_num\""
+	}
+
+	aggregation, err := scope.GetGroupedAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal([]interface{}{
+		modifiersResult{Grouper: 1, Result0: 2, Result1: 1, Result2: 50, Result3: 2},
+		modifiersResult{Grouper: 2, Result0: 1, Result1: 2, Result2: 25, Result3: 0},
+		modifiersResult{Grouper: 3, Result0: 1, Result1: 2, Result2: 25, Result3: 1},
+	}, aggregation)
+}
+
+func (ss *ScopesSuite) TestGetGroupedAggregationsFromParams_Modifiers_invalid() {
+	testCases := []map[string][]string{
+		{
+			"aggregation_column":         {"id"},
+			"aggregation_grouper_column": {"num"},
+			"aggregation_type":           {"count"},
+			"aggregation_modifier":       {"median"},
+		},
+		{
+			"aggregation_column":         {"id|num"},
+			"aggregation_grouper_column": {"num"},
+			"aggregation_type":           {"count|sum"},
+			"aggregation_modifier":       {"rank"},
+		},
+	}
+
+	for _, params := range testCases {
+		_, err := scope.GetGroupedAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+		ss.Error(err)
+	}
+}
+
+func (ss *ScopesSuite) TestGetAggregations_Modifier() {
+	modifier := scope.StandardAggregationModifiers[scope.StandardAggregationModifiersTypeRunningTotal]
+	aggregation := scope.StandardAggregations[scope.StandardAggregationsTypeCount]
+	aggregation.Modifier = &modifier
+
+	_, err := scope.GetAggregations(context.Background(), ss.DB, &[]TestObject{}, []string{"id"}, nil, scope.Aggregations{aggregation})
+	ss.Error(err)
+}
@@ -0,0 +1,44 @@
+package scope
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// filterOperatorIsArray returns true for operators that compare the elements of array columns.
+func filterOperatorIsArray(operator string) bool {
+	switch strings.ToUpper(operator) {
+	case "CONTAINS", "CONTAINED_BY", "OVERLAPS", "ANY":
+		return true
+	default:
+		return false
+	}
+}
+
+// isArrayType returns true if a ResultType is an array, ex. pq.StringArray or slices.UUID.  Byte slices are excluded,
+// since they are scanned from bytea and JSON values.
+func isArrayType(resultType reflect.Type) bool {
+	if resultType == nil {
+		return false
+	}
+
+	for resultType.Kind() == reflect.Ptr {
+		resultType = resultType.Elem()
+	}
+
+	return resultType.Kind() == reflect.Slice && resultType.Elem().Kind() != reflect.Uint8
+}
+
+// getArrayLiteral returns the postgres array literal of `values`, ex. `{"a","b"}`.  The literal is passed as a single
+// arg, so that postgres casts it to the type of the array column it is compared to.
+func getArrayLiteral(values []string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+	elements := make([]string, len(values))
+	for i, value := range values {
+		elements[i] = fmt.Sprintf(`"%v"`, escaper.Replace(value))
+	}
+
+	return fmt.Sprintf("{%v}", strings.Join(elements, ","))
+}
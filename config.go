@@ -0,0 +1,274 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v5"
+)
+
+// Config holds the conventions of the scopes built from params: the names of the params, their separators, pagination,
+// limits, the dialect and the time zone.  A Config is attached to a context by WithConfig, or to a scope collection by
+// Collection.WithConfig, so that APIs within one process can use different conventions.  Configs should be created by
+// NewConfig, and not modified once they are in use.
+type Config struct {
+	// ParamKeys renames params, ex. `{"filter_columns": "fc"}`.  Params that are not renamed keep their names.
+	ParamKeys map[string]string
+	// Namespace prefixes the names of all params, ex. `invoices` for `invoices[filter_columns]`, so that a request can
+	// have separate params for several lists.
+	Namespace string
+	// NamespaceFormat formats the names of params within the Namespace, which is NamespaceFormatBrackets by default.
+	NamespaceFormat string
+	// FilterSeparator separates the clauses of filter, sort, aggregation and facet params, unless a request sets
+	// `filter_separator`.
+	FilterSeparator string
+	// FilterArgsSeparator separates the values of filters with many values, unless a request sets
+	// `filter_args_separator`.
+	FilterArgsSeparator string
+	// PerPageDefault is the number of results per page, unless a request sets `per_page`.
+	PerPageDefault int
+	// PerPageMax is the maximum number of results per page, or 0 for unlimited.
+	PerPageMax int
+	// FilterSimilarityThreshold is the threshold of the SIM filter type, unless a request sets
+	// `filter_similarity_threshold`.
+	FilterSimilarityThreshold float64
+	// FilterOptionsLimitDefault is the number of filter options, unless a request sets `filter_options_limit`.
+	FilterOptionsLimitDefault int
+	// FilterOptionsLimitMax is the maximum number of filter options.
+	FilterOptionsLimitMax int
+	// AggregationPivotColumnsMax is the maximum number of distinct values of a pivot column.
+	AggregationPivotColumnsMax int
+	// SearchConfiguration is the postgres text search configuration of searches.
+	SearchConfiguration string
+	// Limits restrict the complexity of the queries, see Limits.
+	Limits Limits
+	// Dialect is the dialect that columns are generated for by the functions which are not passed a connection.
+	Dialect Dialect
+	// TimeZone is the time zone of the dates without one, ex. `aggregation_comparison_start`.
+	TimeZone *time.Location
+}
+
+// NamespaceFormatBrackets formats namespaced params as `namespace[param]`, ex. `invoices[filter_columns]`.
+const NamespaceFormatBrackets = "%v[%v]"
+
+// NamespaceFormatDots formats namespaced params as `namespace.param`, ex. `invoices.filter_columns`.
+const NamespaceFormatDots = "%v.%v"
+
+// NewConfig returns a Config with the package defaults, ex. pop.PaginatorPerPageDefault and DefaultLimits.
+func NewConfig() *Config {
+	return newConfig(currentConfigDefaults())
+}
+
+// configDefaults are the package defaults that a Config is created with.
+type configDefaults struct {
+	PageKey                    string
+	PerPageKey                 string
+	PerPageDefault             int
+	FilterSimilarityThreshold  float64
+	FilterOptionsLimitDefault  int
+	FilterOptionsLimitMax      int
+	AggregationPivotColumnsMax int
+	SearchConfiguration        string
+	Limits                     Limits
+}
+
+// currentConfigDefaults returns the current values of the package defaults.
+func currentConfigDefaults() configDefaults {
+	return configDefaults{
+		PageKey:                    pop.PaginatorPageKey,
+		PerPageKey:                 pop.PaginatorPerPageKey,
+		PerPageDefault:             pop.PaginatorPerPageDefault,
+		FilterSimilarityThreshold:  FilterSimilarityThreshold,
+		FilterOptionsLimitDefault:  FilterOptionsLimitDefault,
+		FilterOptionsLimitMax:      FilterOptionsLimitMax,
+		AggregationPivotColumnsMax: AggregationPivotColumnsMax,
+		SearchConfiguration:        SearchConfiguration,
+		Limits:                     DefaultLimits,
+	}
+}
+
+// newConfig returns a Config with the package defaults `defaults`.
+func newConfig(defaults configDefaults) *Config {
+	return &Config{
+		ParamKeys: map[string]string{
+			"page":     defaults.PageKey,
+			"per_page": defaults.PerPageKey,
+		},
+		NamespaceFormat:            NamespaceFormatBrackets,
+		FilterSeparator:            "|",
+		FilterArgsSeparator:        ",",
+		PerPageDefault:             defaults.PerPageDefault,
+		FilterSimilarityThreshold:  defaults.FilterSimilarityThreshold,
+		FilterOptionsLimitDefault:  defaults.FilterOptionsLimitDefault,
+		FilterOptionsLimitMax:      defaults.FilterOptionsLimitMax,
+		AggregationPivotColumnsMax: defaults.AggregationPivotColumnsMax,
+		SearchConfiguration:        defaults.SearchConfiguration,
+		Limits:                     defaults.Limits,
+		Dialect:                    PostgresDialect,
+		TimeZone:                   time.UTC,
+	}
+}
+
+// WithNamespace returns a copy of the config whose params are within a namespace, ex. `invoices` for
+// `invoices[filter_columns]`.
+func (c *Config) WithNamespace(namespace string) *Config {
+	config := *c
+	config.Namespace = namespace
+
+	return &config
+}
+
+// ParamKey returns the name of the param `key` in requests, ex. `ParamKey("filter_columns")`.  The name is renamed by
+// the ParamKeys, then placed within the Namespace.
+func (c *Config) ParamKey(key string) string {
+	name := key
+	if renamed, ok := c.ParamKeys[key]; ok && renamed != "" {
+		name = renamed
+	}
+
+	if c.Namespace == "" {
+		return name
+	}
+
+	namespaceFormat := c.NamespaceFormat
+	if namespaceFormat == "" {
+		namespaceFormat = NamespaceFormatBrackets
+	}
+
+	return fmt.Sprintf(namespaceFormat, c.Namespace, name)
+}
+
+// configParams are params whose names are mapped by the ParamKeys and Namespace of a config.
+type configParams struct {
+	params buffalo.ParamValues
+	config *Config
+}
+
+// Get returns the value of the param `key`, which is looked up by its name in the config.
+func (p configParams) Get(key string) string {
+	return p.params.Get(p.config.ParamKey(key))
+}
+
+// params returns params which are looked up by their names in the config.  Params which are already looked up through
+// a config are returned unchanged.
+func (c *Config) params(params buffalo.ParamValues) buffalo.ParamValues {
+	if _, ok := params.(configParams); ok || params == nil {
+		return params
+	}
+
+	return configParams{params: params, config: c}
+}
+
+// ForPaginateFromParams paginates a query based on the `page` and `per_page` params, with the pagination of the config.
+func (c *Config) ForPaginateFromParams(params buffalo.ParamValues) pop.ScopeFunc {
+	params = c.params(params)
+
+	page, err := strconv.Atoi(params.Get("page"))
+	if err != nil {
+		page = 1
+	}
+
+	perPage, err := strconv.Atoi(params.Get("per_page"))
+	if err != nil {
+		perPage = c.PerPageDefault
+	}
+
+	return c.Paginate(page, perPage)
+}
+
+// Paginate paginates a query.  Pages start at 1, and the number of results per page is the PerPageDefault if it is not
+// positive, and at most PerPageMax.
+func (c *Config) Paginate(page, perPage int) pop.ScopeFunc {
+	if page < 1 {
+		page = 1
+	}
+
+	if perPage < 1 {
+		perPage = c.PerPageDefault
+	}
+
+	if exceeds(perPage, c.PerPageMax) {
+		perPage = c.PerPageMax
+	}
+
+	return func(q *pop.Query) *pop.Query {
+		return q.Paginate(page, perPage)
+	}
+}
+
+// configContextKey is the context key of the config set by WithConfig.
+type configContextKey struct{}
+
+// WithConfig returns a copy of a context in which scopes are built with a config, see ConfigFromContext.
+func WithConfig(ctx context.Context, config *Config) context.Context {
+	return context.WithValue(ctx, configContextKey{}, config)
+}
+
+// defaultConfigEntry is the config of the contexts without one, and the package defaults that it was created with.
+type defaultConfigEntry struct {
+	defaults configDefaults
+	config   *Config
+}
+
+// defaultConfig holds the *defaultConfigEntry of the contexts without a config, see ConfigFromContext.
+var defaultConfig atomic.Value
+
+// ConfigFromContext returns the config of the scopes built within a context, which is the default config unless it is
+// set by WithConfig, or attached to the scope collection passed to a function.  The default config is a NewConfig that
+// is shared until the package defaults, ex. FilterOptionsLimitDefault, are changed, and it must not be modified.
+func ConfigFromContext(ctx context.Context) *Config {
+	if config, ok := configFromContext(ctx); ok {
+		return config
+	}
+
+	defaults := currentConfigDefaults()
+	if entry, ok := defaultConfig.Load().(*defaultConfigEntry); ok && entry.defaults == defaults {
+		return entry.config
+	}
+
+	entry := &defaultConfigEntry{defaults: defaults, config: newConfig(defaults)}
+	defaultConfig.Store(entry)
+	return entry.config
+}
+
+// configFromContext returns the config set by WithConfig, if any.
+func configFromContext(ctx context.Context) (*Config, bool) {
+	if ctx != nil {
+		if config, ok := ctx.Value(configContextKey{}).(*Config); ok && config != nil {
+			return config, true
+		}
+	}
+
+	return nil, false
+}
+
+// WithNamespace returns a copy of a context in which params are within a namespace, ex. `invoices` for
+// `invoices[filter_columns]`, see Config.WithNamespace.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return WithConfig(ctx, ConfigFromContext(ctx).WithNamespace(namespace))
+}
+
+// withCollectionConfig returns a copy of a context with the config of a scope collection, unless the context already
+// has a config.
+func withCollectionConfig(ctx context.Context, scopes *Collection) context.Context {
+	if scopes == nil || scopes.config == nil {
+		return ctx
+	}
+
+	if _, ok := configFromContext(ctx); ok {
+		return ctx
+	}
+
+	return WithConfig(ctx, scopes.config)
+}
+
+// withParamsConfig returns a copy of a context with the config of a scope collection, and params which are looked up
+// by their names in the config of the context.
+func withParamsConfig(ctx context.Context, params buffalo.ParamValues, scopes *Collection) (context.Context, buffalo.ParamValues) {
+	ctx = withCollectionConfig(ctx, scopes)
+	return ctx, ConfigFromContext(ctx).params(params)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/gobuffalo/pop/v5"
 
@@ -19,20 +20,116 @@ import (
 //
 //  {
 //	  Name:       "test_int_text_value",
-//	  Statement:  "test_int::TEXT",
+//	  Statement:  "CAST(test_int AS TEXT)",
 //	  ResultType: reflect.TypeOf(""),
 //   }
 //
 // Statement can be any valid sql Statement that returns a single value.
 // ResultType is the type of the value returned by statement, and is used to scan the value from the DB.
+// LabelStatement is an optional sql Statement that returns the label displayed for the value of Statement.  If set,
+// filter options for this column are returned as FilterOption values.
+// OptionsSource is an optional FilterOptionsSource, which is used for the filter options of this column instead of the
+// unique values of Statement.
+// Joins are the joins required by Statement, which are added to any query using this column.  See
+// GenerateCustomColumnsForSubobjects.
+// DisplayGroup and DisplayOrder are optional hints for displaying the column, ex. in a column picker.  Columns are
+// ordered by DisplayOrder, then by the order of the fields of the model, followed by the custom columns in the order
+// they are declared.  Columns of the same DisplayGroup are then kept together, at the position of the first column of
+// the group.
 type CustomColumn struct {
-	Name       string
-	Statement  string
-	ResultType reflect.Type
+	Name           string
+	Statement      string
+	ResultType     reflect.Type
+	LabelStatement string
+	OptionsSource  *FilterOptionsSource
+	Joins          []Join
+	DisplayGroup   string
+	DisplayOrder   int
 }
 
 type CustomColumns []CustomColumn
 
+// orderColumns orders columns by their DisplayOrder and DisplayGroup, keeping columns in their existing order
+// otherwise.  Columns are ordered in place, and are only copied if they have display hints.
+func orderColumns(columns []CustomColumn) {
+	hasOrder, hasGroup := false, false
+	for _, column := range columns {
+		hasOrder = hasOrder || column.DisplayOrder != 0
+		hasGroup = hasGroup || column.DisplayGroup != ""
+	}
+
+	if hasOrder {
+		sort.SliceStable(columns, func(i, j int) bool {
+			return columns[i].DisplayOrder < columns[j].DisplayOrder
+		})
+	}
+
+	if !hasGroup {
+		return
+	}
+
+	// Each column is positioned by the first column of its group, or by itself if it is not grouped.
+	positions := make([]int, len(columns))
+	groupPositions := make(map[string]int)
+	for i, column := range columns {
+		positions[i] = i
+		if column.DisplayGroup == "" {
+			continue
+		}
+
+		if position, ok := groupPositions[column.DisplayGroup]; ok {
+			positions[i] = position
+		} else {
+			groupPositions[column.DisplayGroup] = i
+		}
+	}
+
+	indexes := make([]int, len(columns))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return positions[indexes[i]] < positions[indexes[j]]
+	})
+
+	ordered := make([]CustomColumn, len(columns))
+	for i, index := range indexes {
+		ordered[i] = columns[index]
+	}
+	copy(columns, ordered)
+}
+
+// WithLabelColumn returns a copy of the CustomColumns, where the column `name` is labeled by the Statement of the column
+// `labelName`.  This is useful to label the id of a subobject, ex.
+//
+//	columns, err := GenerateCustomColumnsForSubobject(&Address{}, "address", "addresses.id = houses.address_id")
+//	columns, err = columns.WithLabelColumn("address.id", "address.city")
+func (c CustomColumns) WithLabelColumn(name, labelName string) (CustomColumns, error) {
+	var labelColumn *CustomColumn
+	for i := range c {
+		if c[i].Name == labelName {
+			labelColumn = &c[i]
+			break
+		}
+	}
+
+	if labelColumn == nil {
+		return nil, errors.New("label column not found")
+	}
+
+	labeledColumns := make(CustomColumns, len(c))
+	copy(labeledColumns, c)
+	for i := range labeledColumns {
+		if labeledColumns[i].Name == name {
+			labeledColumns[i].LabelStatement = labelColumn.Statement
+			labeledColumns[i].Joins = append(append([]Join{}, labeledColumns[i].Joins...), labelColumn.Joins...)
+			return labeledColumns, nil
+		}
+	}
+
+	return nil, errors.New("column not found")
+}
+
 type CustomFilterable interface {
 	GetCustomFilters(ctx context.Context) CustomColumns
 }
@@ -44,40 +141,18 @@ type CustomSortable interface {
 // GetAllFilterColumns is a utility in order to automatically get a list of all columns that can be filtered on for
 // the referenced model.
 func GetAllFilterColumns(ctx context.Context, modelPtr interface{}) ([]CustomColumn, error) {
-	v := reflect.ValueOf(modelPtr)
-	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
-		return nil, errors.New("pointer to struct expected")
-	}
-
-	model := v.Elem().Interface()
-
-	validColumns, err := getAllQueryableColumns(modelPtr)
+	metadata, err := getModelMetadata(modelPtr, DialectFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	validColumnsMap := make(map[string]CustomColumn, len(validColumns))
-	for i, col := range validColumns {
-		validColumnsMap[col.Name] = validColumns[i]
-	}
-
-	// Get all of the custom filters.
-	if customFilterable, ok := model.(CustomFilterable); ok {
-		customColumns := customFilterable.GetCustomFilters(ctx)
-
-		for i, col := range customColumns {
-			validColumnsMap[col.Name] = customColumns[i]
-		}
-	}
-
-	allColumns := make([]CustomColumn, len(validColumnsMap))
-	i := 0
-	for j := range validColumnsMap {
-		allColumns[i] = validColumnsMap[j]
-		i++
+	// Get all of the custom filters, which override the columns of the model.
+	var customColumns CustomColumns
+	if customFilterable, ok := modelPtr.(CustomFilterable); ok {
+		customColumns = customFilterable.GetCustomFilters(ctx)
 	}
 
-	return allColumns, nil
+	return metadata.withCustomColumns(customColumns), nil
 }
 
 // GetAllFilterColumnNames is a utility in order to automatically get a list of all tags that can be filtered on for
@@ -99,40 +174,18 @@ func GetAllFilterColumnNames(ctx context.Context, modelPtr interface{}) ([]strin
 // GetAllSortColumns is a utility in order to automatically get a list of all columns that can be sorted on for
 // the referenced model.
 func GetAllSortColumns(ctx context.Context, modelPtr interface{}) ([]CustomColumn, error) {
-	v := reflect.ValueOf(modelPtr)
-	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
-		return nil, errors.New("pointer to struct expected")
-	}
-
-	model := v.Elem().Interface()
-
-	validColumns, err := getAllQueryableColumns(modelPtr)
+	metadata, err := getModelMetadata(modelPtr, DialectFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	validColumnsMap := make(map[string]CustomColumn, len(validColumns))
-	for i, col := range validColumns {
-		validColumnsMap[col.Name] = validColumns[i]
+	// Get all of the custom sorts, which override the columns of the model.
+	var customColumns CustomColumns
+	if customSortable, ok := modelPtr.(CustomSortable); ok {
+		customColumns = customSortable.GetCustomSorts(ctx)
 	}
 
-	// Get all of the custom sorts.
-	if customSortable, ok := model.(CustomSortable); ok {
-		customColumns := customSortable.GetCustomSorts(ctx)
-
-		for i, col := range customColumns {
-			validColumnsMap[col.Name] = customColumns[i]
-		}
-	}
-
-	allColumns := make([]CustomColumn, len(validColumnsMap))
-	i := 0
-	for j := range validColumnsMap {
-		allColumns[i] = validColumnsMap[j]
-		i++
-	}
-
-	return allColumns, nil
+	return metadata.withCustomColumns(customColumns), nil
 }
 
 // GetAllSortColumnNames is a utility in order to automatically get a list of all tags that can be sorted on for
@@ -153,6 +206,7 @@ func GetAllSortColumnNames(ctx context.Context, modelPtr interface{}) ([]string,
 
 // GenerateCustomColumnsForSubobject is a utility in order to automatically create custom filter columns for a model
 // related to the model you are filtering.
+// Identifiers are quoted as needed for postgres, see QuoteIdentifier.
 //
 // For example, assume you have a table Houses, and each house has an address_id field pointing to an Addresses table.
 // In order to make houses sortable by the columns of the Address table, you will need to implement 'CustomSortable' and
@@ -184,16 +238,13 @@ func GenerateCustomColumnsForSubobject(subobjectPtr interface{}, subobjectJsonTa
 
 	customColumns := CustomColumns{}
 
-	fields := util.ValuesForStructTag(subobject, "json")
-	for _, col := range fields {
-		field, ok := util.FieldWithJsonTagValue(subobject, col)
-		if !ok {
-			continue
-		}
+	fields, err := util.ModelFields(subobject)
+	if err != nil {
+		return nil, err
+	}
 
-		// Look up the database column name for this field.
-		dbColumn, ok := util.LookupForStructFieldTag(subobject, field, "db")
-		if !ok || dbColumn == "-" {
+	for _, field := range fields {
+		if field.JSONName == "" || field.DBName == "" {
 			continue
 		}
 
@@ -206,11 +257,11 @@ func GenerateCustomColumnsForSubobject(subobjectPtr interface{}, subobjectJsonTa
 		}
 
 		customColumn := CustomColumn{
-			Name:       fmt.Sprintf("%v.%v", subobjectJsonTag, col),
-			ResultType: util.GetFieldByName(subobjectPtr, field).Type(),
+			Name:       fmt.Sprintf("%v.%v", subobjectJsonTag, field.JSONName),
+			ResultType: field.Type,
 
 			// Select [field_db_tag] from [subobject tablename] where [join clause]
-			Statement: fmt.Sprintf("(select %v from %v where %v)", dbColumn, tablename, joinClause),
+			Statement: fmt.Sprintf("(select %v from %v where %v)", quoteIdentifierPart(PostgresDialect, field.DBName), QuoteIdentifier(PostgresDialect, tablename), joinClause),
 		}
 
 		customColumns = append(customColumns, customColumn)
@@ -222,8 +273,8 @@ func GenerateCustomColumnsForSubobject(subobjectPtr interface{}, subobjectJsonTa
 // getAllQueryableColumns is a utility in order to automatically get a list of custom columns for all tags that can be
 // filtered on this model by default, without including the interfaces CustomFilterable and CustomSortable.  In other
 // words, this does not include any custom columns that may have been added, it only returns the columns on this model
-// with both json and db tags.
-func getAllQueryableColumns(modelPtr interface{}) ([]CustomColumn, error) {
+// with both json and db tags.  Statements are quoted as needed for `dialect`.
+func getAllQueryableColumns(modelPtr interface{}, dialect Dialect) ([]CustomColumn, error) {
 	v := reflect.ValueOf(modelPtr)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
 		return nil, errors.New("pointer to struct expected")
@@ -236,23 +287,21 @@ func getAllQueryableColumns(modelPtr interface{}) ([]CustomColumn, error) {
 
 	validColumns := make([]CustomColumn, 0)
 
-	// Get all of the json tags that are exposed to the user.
-	structJsonTags := util.ValuesForStructTag(model, "json")
-	for i, structJsonTag := range structJsonTags {
-		structFieldName, ok := util.FieldWithJsonTagValue(model, structJsonTag)
-		if !ok {
-			continue
-		}
+	// Get all of the fields that are exposed to the user, including those of embedded structs.
+	fields, err := util.ModelFields(model)
+	if err != nil {
+		return nil, err
+	}
 
-		dbColumn, ok := util.LookupForStructFieldTag(model, structFieldName, "db")
-		if !ok || dbColumn == "-" {
+	for _, field := range fields {
+		if field.JSONName == "" || field.DBName == "" {
 			continue
 		}
 
 		customColumn := CustomColumn{
-			Name:       structJsonTags[i],
-			ResultType: util.GetFieldByName(modelPtr, structFieldName).Type(),
-			Statement:  fmt.Sprintf("%v.%v", tableName, dbColumn),
+			Name:       field.JSONName,
+			ResultType: field.Type,
+			Statement:  quoteColumn(dialect, tableName, field.DBName),
 		}
 
 		validColumns = append(validColumns, customColumn)
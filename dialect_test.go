@@ -0,0 +1,201 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+
+	"github.com/alphaflow/scope"
+)
+
+func (ss *ScopesSuite) TestDialect_Comparison() {
+	testCases := []struct {
+		Dialect    scope.Dialect
+		FilterType string
+		Expected   string
+	}{
+		{scope.PostgresDialect, "eq", "name = ?"},
+		{scope.PostgresDialect, "ilk", "name ilike ?"},
+		{scope.PostgresDialect, "df", "name is distinct from ?"},
+		{scope.MySQLDialect, "eq", "name = ?"},
+		{scope.MySQLDialect, "ilk", "LOWER(name) LIKE LOWER(?)"},
+		{scope.MySQLDialect, "nilk", "LOWER(name) NOT LIKE LOWER(?)"},
+		{scope.MySQLDialect, "df", "NOT (name <=> ?)"},
+		{scope.MySQLDialect, "ndf", "name <=> ?"},
+		{scope.SQLiteDialect, "lt", "name < ?"},
+		{scope.SQLiteDialect, "ilk", `LOWER(name) LIKE LOWER(?) ESCAPE '\'`},
+		{scope.SQLiteDialect, "nlk", `name NOT LIKE ? ESCAPE '\'`},
+		{scope.SQLiteDialect, "df", "name IS NOT ?"},
+		{scope.SQLiteDialect, "ndf", "name IS ?"},
+	}
+
+	for _, tc := range testCases {
+		ss.Equal(tc.Expected, tc.Dialect.Comparison(tc.FilterType, "name"), tc.Dialect.Name()+" "+tc.FilterType)
+	}
+}
+
+func (ss *ScopesSuite) TestDialect_Cast() {
+	ss.Equal("CAST(name AS TEXT)", scope.PostgresDialect.Cast("name", "TEXT"))
+	ss.Equal("CAST(name AS CHAR)", scope.MySQLDialect.Cast("name", "TEXT"))
+	ss.Equal("CAST(name AS DATETIME)", scope.MySQLDialect.Cast("name", "TIMESTAMPTZ"))
+	ss.Equal("CAST(name AS TEXT)", scope.SQLiteDialect.Cast("name", "TEXT"))
+	ss.Equal("DATE(name)", scope.SQLiteDialect.Cast("name", "DATE"))
+}
+
+func (ss *ScopesSuite) TestDialect_QuoteAndPlaceholder() {
+	ss.Equal(`"na""me"`, scope.PostgresDialect.Quote(`na"me`))
+	ss.Equal("`na``me`", scope.MySQLDialect.Quote("na`me"))
+	ss.Equal(`"name"`, scope.SQLiteDialect.Quote("name"))
+
+	ss.Equal("$2", scope.PostgresDialect.Placeholder(2))
+	ss.Equal("?", scope.MySQLDialect.Placeholder(2))
+	ss.Equal("?", scope.SQLiteDialect.Placeholder(2))
+}
+
+func (ss *ScopesSuite) TestDialect_Supports() {
+	for _, feature := range []string{"SIM", "CT", "ANY", scope.FeatureJSONPaths, scope.FeatureSearch, scope.FeatureGroupingSets, scope.FeatureQueryCost} {
+		ss.True(scope.PostgresDialect.Supports(feature), feature)
+		ss.False(scope.MySQLDialect.Supports(feature), feature)
+		ss.False(scope.SQLiteDialect.Supports(feature), feature)
+	}
+
+	for _, filterType := range []string{"eq", "ilk", "in", "bt", "nn"} {
+		ss.True(scope.MySQLDialect.Supports(filterType), filterType)
+		ss.True(scope.SQLiteDialect.Supports(filterType), filterType)
+	}
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_MySQL() {
+	conn, err := pop.NewConnection(&pop.ConnectionDetails{
+		Dialect:  "mysql",
+		Database: "scope_test",
+		Host:     "127.0.0.1",
+		Port:     "3306",
+		User:     "root",
+	})
+	ss.NoError(err)
+
+	params := url.Values{
+		"filter_columns": {"custom_filter|id"},
+		"filter_types":   {"ilk|df"},
+		"filter_values":  {"12%|00000000-0000-0000-0000-000000000000"},
+		"filter_logic":   {"and"},
+	}
+
+	s, err := scope.ForFiltersFromParams(context.Background(), TestModel{}, params)
+	ss.NoError(err)
+
+	query, args := conn.Q().Scope(s).ToSQL(&pop.Model{Value: TestModel{}})
+	ss.Contains(query, "WHERE (LOWER((SELECT '1234')) LIKE LOWER(?) AND NOT (test_models.id <=> ?))")
+	ss.Len(args, 2)
+
+	// Postgres specific filter types are not supported, so the filter matches no rows.
+	params.Set("filter_types", "sim|df")
+	s, err = scope.ForFiltersFromParams(context.Background(), TestModel{}, params)
+	ss.NoError(err)
+
+	query, _ = conn.Q().Scope(s).ToSQL(&pop.Model{Value: TestModel{}})
+	ss.Contains(query, "WHERE 1 = 0")
+	ss.NotContains(query, "similarity")
+
+	// The error is returned if the scope is built for the dialect of the connection.
+	_, err = scope.ForFiltersFromParams(scope.WithDialect(context.Background(), scope.GetDialect(conn)), TestModel{}, params)
+	ss.Error(err)
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_SQLite() {
+	if ss.dialect() != scope.SQLiteDialect {
+		ss.T().Skip("the suite is not run against SQLite")
+	}
+
+	// The filter is valid on postgres, but not on SQLite, so it matches no rows instead of sending the postgres clause.
+	ss.NoError(ss.DB.Create(&TestObject{}))
+	params := url.Values{"filter_columns": {"id"}, "filter_types": {"sim"}, "filter_values": {"test"}}
+	s, err := scope.ForFiltersFromParams(context.Background(), TestObject{}, params)
+	ss.NoError(err)
+
+	var objects []TestObject
+	ss.NoError(ss.DB.Scope(s).All(&objects))
+	ss.Empty(objects)
+
+	params = url.Values{"sort_columns": {"id"}, "sort_directions": {"sim"}, "sort_values": {"test"}}
+	s, err = scope.ForSortFromParams(context.Background(), TestObject{}, params)
+	ss.NoError(err)
+
+	ss.NoError(ss.DB.Scope(s).All(&objects))
+	ss.Empty(objects)
+}
+
+type TestReservedObject struct {
+	ID       uuid.UUID `json:"id" db:"id"`
+	Order    int       `json:"order" db:"order"`
+	Group    string    `json:"group" db:"group"`
+	UserName string    `json:"user_name" db:"UserName"`
+}
+
+func (t TestReservedObject) TableName() string {
+	return "billing.invoices"
+}
+
+func (ss *ScopesSuite) TestQuoteIdentifier() {
+	ss.Equal("invoices", scope.QuoteIdentifier(scope.PostgresDialect, "invoices"))
+	ss.Equal("billing.invoices", scope.QuoteIdentifier(scope.PostgresDialect, "billing.invoices"))
+	ss.Equal(`"order"`, scope.QuoteIdentifier(scope.PostgresDialect, "order"))
+	ss.Equal(`billing."User"`, scope.QuoteIdentifier(scope.PostgresDialect, "billing.User"))
+	ss.Equal("`group`", scope.QuoteIdentifier(scope.MySQLDialect, "group"))
+	ss.Equal(`"already quoted"`, scope.QuoteIdentifier(scope.PostgresDialect, `"already quoted"`))
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_Quoted() {
+	filters, err := scope.GetAllFilterColumns(context.Background(), &TestReservedObject{})
+	ss.NoError(err)
+
+	filtersMap := make(map[string]scope.CustomColumn, len(filters))
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+
+	// Schema-qualified tables are referenced by their pop alias.
+	ss.Equal("billing_invoices.id", filtersMap["id"].Statement)
+	ss.Equal(`billing_invoices."order"`, filtersMap["order"].Statement)
+	ss.Equal(`billing_invoices."group"`, filtersMap["group"].Statement)
+	ss.Equal(`billing_invoices."UserName"`, filtersMap["user_name"].Statement)
+
+	filters, err = scope.GetAllFilterColumns(scope.WithDialect(context.Background(), scope.MySQLDialect), &TestReservedObject{})
+	ss.NoError(err)
+
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+
+	ss.Equal("billing_invoices.`order`", filtersMap["order"].Statement)
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_ReservedWords() {
+	params := url.Values{
+		"filter_columns":  {"order|user_name"},
+		"filter_types":    {"gt|eq"},
+		"filter_values":   {"1|test"},
+		"filter_logic":    {"and"},
+		"sort_columns":    {"group"},
+		"sort_directions": {"asc"},
+	}
+
+	filters, err := scope.ForFiltersFromParams(context.Background(), TestReservedObject{}, params)
+	ss.NoError(err)
+
+	sorts, err := scope.ForSortFromParams(context.Background(), TestReservedObject{}, params)
+	ss.NoError(err)
+
+	query, args := ss.dryRunDB("postgres").Q().Scope(filters).Scope(sorts).ToSQL(&pop.Model{Value: TestReservedObject{}})
+	ss.Contains(query, `FROM billing.invoices AS billing_invoices`)
+	ss.Contains(query, `WHERE (billing_invoices."order" > $1 AND billing_invoices."UserName" = $2)`)
+	ss.Contains(query, `ORDER BY billing_invoices."group" ASC`)
+	ss.Len(args, 2)
+
+	id := "5c2d7cfe-3d5b-4c4a-b3e5-9b1f9cbb5b2a"
+	query, _ = ss.dryRunDB("postgres").Q().Scope(scope.ForIDForModel(id, TestReservedObject{})).ToSQL(&pop.Model{Value: TestReservedObject{}})
+	ss.Contains(query, `WHERE billing_invoices.id = $1`)
+}
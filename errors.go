@@ -0,0 +1,145 @@
+package scope
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ParamErrorCode identifies the kind of a ParamError, so that clients can handle errors without parsing their messages.
+type ParamErrorCode string
+
+const (
+	// ParamErrorCodeMismatchedParams is the code of a missing param, or params of different lengths.
+	ParamErrorCodeMismatchedParams ParamErrorCode = "mismatched_params"
+	// ParamErrorCodeDuplicateParam is the code of a column or aggregation which is requested more than once.
+	ParamErrorCodeDuplicateParam ParamErrorCode = "duplicate_param"
+	// ParamErrorCodeInvalidField is the code of a column which is not filterable, sortable or a valid path.
+	ParamErrorCodeInvalidField ParamErrorCode = "invalid_field"
+	// ParamErrorCodeInvalidType is the code of an unknown filter type, sort direction, aggregation or other operator.
+	ParamErrorCodeInvalidType ParamErrorCode = "invalid_type"
+	// ParamErrorCodeInvalidValue is the code of a value which can't be parsed or is out of range.
+	ParamErrorCodeInvalidValue ParamErrorCode = "invalid_value"
+	// ParamErrorCodeUnsupported is the code of a valid param which is not supported by the dialect or in combination
+	// with the other params.
+	ParamErrorCodeUnsupported ParamErrorCode = "unsupported"
+	// ParamErrorCodeLimitExceeded is the code of a request which would return more results than are allowed.
+	ParamErrorCodeLimitExceeded ParamErrorCode = "limit_exceeded"
+)
+
+// ParamError is an error in the params of a request, ex. an unknown filter field.  ParamErrors are client errors,
+// unlike the errors of the database, and can be found with `errors.As`:
+//
+//	var paramErr *scope.ParamError
+//	if errors.As(err, &paramErr) {
+//		// paramErr.Code, paramErr.Param, paramErr.Index and paramErr.Value describe the error.
+//	}
+type ParamError struct {
+	// Code is the kind of the error.
+	Code ParamErrorCode
+	// Param is the name of the param in error, ex. `filter_columns`.
+	Param string
+	// Index is the index of the clause in error within the param, or -1 if the error is not in a single clause.
+	Index int
+	// Value is the offending value.
+	Value string
+	// Message is a description of the error.
+	Message string
+}
+
+// Error returns the description of the error.
+func (e *ParamError) Error() string {
+	return e.Message
+}
+
+// newParamError returns a ParamError which is not in a single clause.
+func newParamError(code ParamErrorCode, param, value, format string, args ...interface{}) *ParamError {
+	return &ParamError{
+		Code:    code,
+		Param:   param,
+		Index:   -1,
+		Value:   value,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// newClauseError returns a ParamError in the clause `index` of the param.
+func newClauseError(code ParamErrorCode, param string, index int, value, format string, args ...interface{}) *ParamError {
+	err := newParamError(code, param, value, format, args...)
+	err.Index = index
+	return err
+}
+
+// atClause sets the param and clause index of a ParamError returned by a helper which doesn't know them.  Other errors
+// are returned unchanged.
+func atClause(err error, param string, index int) error {
+	var paramErr *ParamError
+	if errors.As(err, &paramErr) {
+		if paramErr.Param == "" {
+			paramErr.Param = param
+		}
+
+		if paramErr.Index < 0 {
+			paramErr.Index = index
+		}
+	}
+
+	return err
+}
+
+// ProblemContentType is the media type of an RFC 7807 problem details object.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object.  The code, param, index and value of a ParamError are extension
+// members of the problem.
+type Problem struct {
+	Type   string         `json:"type"`
+	Title  string         `json:"title"`
+	Status int            `json:"status"`
+	Detail string         `json:"detail,omitempty"`
+	Code   ParamErrorCode `json:"code,omitempty"`
+	Param  string         `json:"param,omitempty"`
+	Index  *int           `json:"index,omitempty"`
+	Value  string         `json:"value,omitempty"`
+}
+
+// NewProblem returns the problem details of an error.  ParamErrors are bad requests.  Any other error is an internal
+// server error without any detail, so that the errors of the database are not exposed to clients.
+func NewProblem(err error) Problem {
+	var paramErr *ParamError
+	if !errors.As(err, &paramErr) {
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+		}
+	}
+
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: paramErr.Message,
+		Code:   paramErr.Code,
+		Param:  paramErr.Param,
+		Value:  paramErr.Value,
+	}
+
+	if paramErr.Index >= 0 {
+		index := paramErr.Index
+		problem.Index = &index
+	}
+
+	return problem
+}
+
+// WriteProblem writes the problem details of an error to `w` as problem+json, with the status of the problem.
+func WriteProblem(w http.ResponseWriter, err error) error {
+	problem := NewProblem(err)
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	return json.NewEncoder(w).Encode(problem)
+}
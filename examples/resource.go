@@ -23,10 +23,12 @@ func registerToDosHandlers(app *buffalo.App) {
 
 	// Example endpoints
 	app.GET("/todos/filter_options", tdr.FilterOptions)
+	app.GET("/todos/filter_facets", tdr.FilterFacets)
 	app.GET("/todos/filter_columns", tdr.FilterColumns)
 	app.GET("/todos/sort_columns", tdr.SortColumns)
 	app.GET("/todos/aggregate", tdr.Aggregate)
 	app.GET("/todos/grouped_aggregate", tdr.GroupedAggregate)
+	app.GET("/todos/pivot_aggregate", tdr.PivotAggregate)
 	app.GET("/todos", tdr.List)
 }
 
@@ -36,22 +38,28 @@ func (tdr toDosResource) List(c buffalo.Context) error {
 	// Generic filtering
 	filterScope, err := scope.ForFiltersFromParams(c, ToDo{}, c.Params())
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
+	}
+
+	// Full text search
+	searchScope, err := scope.ForSearchFromParams(c, ToDo{}, c.Params())
+	if err != nil {
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	// Generic ordering
 	orderScope, err := scope.ForSortFromParams(c, ToDo{}, c.Params())
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	// Pagination
 	paginateScope := scope.ForPaginateFromParams(c.Params())
 
 	sc := scope.NewCollection(tx)
-	sc.Push(filterScope, orderScope, paginateScope)
+	sc.Push(filterScope, searchScope, orderScope, paginateScope)
 	if err := tx.Scope(sc.Flatten()).All(toDos); err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	return c.Render(http.StatusOK, r.Auto(c, toDos))
@@ -62,14 +70,14 @@ func (tdr toDosResource) Aggregate(c buffalo.Context) error {
 	// Generic filtering in conjunction with aggregation is very powerful.
 	filterScope, err := scope.ForFiltersFromParams(c, ToDo{}, c.Params())
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	sc := scope.NewCollection(tx)
 	sc.Push(filterScope)
 	aggregate, err := scope.GetAggregationsFromParams(c, tx, &ToDos{}, c.Params(), sc)
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	return c.Render(http.StatusOK, r.Auto(c, aggregate))
@@ -80,7 +88,7 @@ func (tdr toDosResource) GroupedAggregate(c buffalo.Context) error {
 	// Generic filtering in conjunction with aggregation is very powerful.
 	filterScope, err := scope.ForFiltersFromParams(c, ToDo{}, c.Params())
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	sc := scope.NewCollection(tx)
@@ -88,28 +96,57 @@ func (tdr toDosResource) GroupedAggregate(c buffalo.Context) error {
 
 	groupedAggregates, err := scope.GetGroupedAggregationsFromParams(c, tx, &ToDos{}, c.Params(), sc)
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	return c.Render(http.StatusOK, r.Auto(c, groupedAggregates))
 }
 
-// FilterOptions gets all values in the system for the supplied filter_column.
+// PivotAggregate gets aggregate statistics as a matrix of two grouping columns
+func (tdr toDosResource) PivotAggregate(c buffalo.Context) error {
+	filterScope, err := scope.ForFiltersFromParams(c, ToDo{}, c.Params())
+	if err != nil {
+		return scope.WriteProblem(c.Response(), err)
+	}
+
+	sc := scope.NewCollection(tx)
+	sc.Push(filterScope)
+
+	pivotAggregate, err := scope.GetPivotAggregationsFromParams(c, tx, &ToDos{}, c.Params(), sc)
+	if err != nil {
+		return scope.WriteProblem(c.Response(), err)
+	}
+
+	return c.Render(http.StatusOK, r.Auto(c, pivotAggregate))
+}
+
+// FilterOptions gets a page of values in the system for the supplied filter_column.
 func (tdr toDosResource) FilterOptions(c buffalo.Context) error {
 	sc := scope.NewCollection(tx)
-	filterOptions, err := scope.GetFilterOptions(c, tx, &ToDos{}, c.Param("filter_column"), sc)
+	filterOptions, err := scope.GetFilterOptionsFromParams(c, tx, &ToDos{}, c.Params(), sc)
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	return c.Render(http.StatusOK, r.Auto(c, filterOptions))
 }
 
+// FilterFacets gets all values and their counts for the supplied facet_columns, applying the filters on all other columns.
+func (tdr toDosResource) FilterFacets(c buffalo.Context) error {
+	sc := scope.NewCollection(tx)
+	filterFacets, err := scope.GetFilterFacetsFromParams(c, tx, &ToDos{}, c.Params(), sc)
+	if err != nil {
+		return scope.WriteProblem(c.Response(), err)
+	}
+
+	return c.Render(http.StatusOK, r.Auto(c, filterFacets))
+}
+
 // FilterColumns gets all filterable columns.
 func (tdr toDosResource) FilterColumns(c buffalo.Context) error {
 	filterColumns, err := scope.GetAllFilterColumnNames(c, &ToDo{})
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	return c.Render(http.StatusOK, r.Auto(c, filterColumns))
@@ -119,7 +156,7 @@ func (tdr toDosResource) FilterColumns(c buffalo.Context) error {
 func (tdr toDosResource) SortColumns(c buffalo.Context) error {
 	sortColumns, err := scope.GetAllSortColumnNames(c, &ToDo{})
 	if err != nil {
-		return c.Render(http.StatusBadRequest, r.Auto(c, err))
+		return scope.WriteProblem(c.Response(), err)
 	}
 
 	return c.Render(http.StatusOK, r.Auto(c, sortColumns))
@@ -0,0 +1,396 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope/util"
+)
+
+// FilterFacet is the set of filter options for a single column, along with the number of rows for each option.
+type FilterFacet struct {
+	Name    string              `json:"name"`
+	Options []FilterFacetOption `json:"options"`
+}
+
+// FilterFacetOption is a single value of a FilterFacet, along with the number of rows that have that value.
+type FilterFacetOption struct {
+	Value interface{} `json:"value"`
+	Count int         `json:"count"`
+}
+
+// GetFilterFacetsFromParams returns the FilterFacet for each of the `facet_columns` of modelsPtr, restricting by the
+// scope collection scopes and the filter params.
+func GetFilterFacetsFromParams(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, params buffalo.ParamValues, scopes *Collection) ([]FilterFacet, error) {
+	ctx, params = withParamsConfig(ctx, params, scopes)
+
+	if util.IsBlank(params.Get("facet_columns")) {
+		return nil, newParamError(ParamErrorCodeMismatchedParams, "facet_columns", "", "missing facet parameters")
+	}
+
+	columnNames := strings.Split(params.Get("facet_columns"), getFilterSeparator(ctx, params))
+	return GetFilterFacets(ctx, tx, modelsPtr, columnNames, params, scopes)
+}
+
+// GetFilterFacets returns the unique values of each column in `columnNames` of modelsPtr along with their row counts,
+// restricting by the scope collection scopes and the filter params.
+//
+// Each facet is restricted by all of the filter params except the filters on its own column, so that selecting a value
+// of a facet does not remove the other values of that facet.  This is the standard behaviour of faceted navigation.
+//
+// `columnNames` are either a CustomColumn returned by the CustomFilterable interface, or a field specified by the json
+// tag.  This is the same as the acceptable values for `filter_columns` in ForFiltersFromParams.
+func GetFilterFacets(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, columnNames []string, params buffalo.ParamValues, scopes *Collection) ([]FilterFacet, error) {
+	ctx, params = withParamsConfig(ctx, params, scopes)
+
+	v := reflect.ValueOf(modelsPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return nil, errors.New("pointer to slice expected")
+	}
+
+	// Each facet is a grouping set, which only postgres supports.
+	if dialect := getDialect(tx); !dialect.Supports(FeatureGroupingSets) {
+		return nil, newParamError(ParamErrorCodeUnsupported, "facet_columns", "", "filter facets are not supported by %v", dialect.Name())
+	}
+
+	models := v.Elem()
+	modelPtr := reflect.New(models.Type().Elem()).Interface()
+	model := reflect.Indirect(reflect.ValueOf(modelPtr)).Interface()
+
+	// Columns are generated for the dialect of the connection.
+	ctx = WithDialect(ctx, getDialect(tx))
+	filterColumns, err := GetAllFilterColumns(ctx, modelPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	customColumns := CustomColumns{}
+	facetClauses := make([]string, len(columnNames))
+	facetArgs := make([][]interface{}, len(columnNames))
+	columnNameSet := make(map[string]bool)
+	joins := make([]Join, 0)
+	for i, columnName := range columnNames {
+		if _, ok := columnNameSet[columnName]; ok {
+			return nil, newClauseError(ParamErrorCodeDuplicateParam, "facet_columns", i, columnName, "duplicate facet parameter")
+		}
+
+		columnNameSet[columnName] = true
+
+		var column *CustomColumn
+		for j, filterColumn := range filterColumns {
+			if columnName == filterColumn.Name {
+				column = &filterColumns[j]
+				break
+			}
+		}
+
+		if column == nil {
+			return nil, newClauseError(ParamErrorCodeInvalidField, "facet_columns", i, columnName, "invalid filter field: %v", columnName)
+		}
+
+		customColumns = append(customColumns, *column)
+
+		// Each facet ignores the filters on its own column.
+		var clauseJoins []Join
+		facetClauses[i], facetArgs[i], clauseJoins, err = getFilterClauseFromParams(ctx, model, params, map[string]bool{columnName: true}, getDialect(tx))
+		if err != nil {
+			return nil, err
+		}
+
+		joins = append(joins, clauseJoins...)
+
+		if util.IsBlank(facetClauses[i]) {
+			facetClauses[i] = PassQuery
+		}
+	}
+
+	tableName := (&pop.Model{Value: modelPtr}).TableName()
+	return getCustomFilterFacets(ctx, tx, tableName, customColumns, facetClauses, facetArgs, joins, scopes)
+}
+
+// filterLogicToken is a token of the filter logic: a parenthesis, a logic operator, or the clause at index `clause`.
+type filterLogicToken struct {
+	value  string
+	clause int
+}
+
+// filterLogicNode is a node of the tree of the filter logic, which is either the clause at index `clause`, or a group
+// of children joined by `logic`.
+type filterLogicNode struct {
+	clause   int
+	logic    string
+	children []*filterLogicNode
+}
+
+// filterLogicParser parses the tokens of the filter logic into a tree, where AND takes precedence over OR as it does in
+// SQL.
+type filterLogicParser struct {
+	tokens []filterLogicToken
+	pos    int
+}
+
+// getExcludedFilterClauses returns the clause which replaces each of the `excluded` filter clauses, so that the filter
+// logic is the same as if the excluded clauses were removed.  A group of excluded clauses is replaced by the identity
+// of the logic joining it to the rest of the filter: PassQuery within AND, and FailQuery within OR.
+func getExcludedFilterClauses(excluded map[int]bool, clauseCount int, logic []string, leftParens, rightParens map[int]string) (map[int]string, error) {
+	tokens := make([]filterLogicToken, 0)
+	for i := 0; i < clauseCount; i++ {
+		for range leftParens[i] {
+			tokens = append(tokens, filterLogicToken{value: "(", clause: -1})
+		}
+
+		tokens = append(tokens, filterLogicToken{clause: i})
+
+		for range rightParens[i] {
+			tokens = append(tokens, filterLogicToken{value: ")", clause: -1})
+		}
+
+		if i < len(logic) {
+			l, ok := filterLogics[strings.ToUpper(logic[i])]
+			if !ok {
+				return nil, newClauseError(ParamErrorCodeInvalidType, "filter_logic", i, logic[i], "invalid filter logic: %v", logic[i])
+			}
+
+			tokens = append(tokens, filterLogicToken{value: l, clause: -1})
+		}
+	}
+
+	p := &filterLogicParser{tokens: tokens}
+	root, err := p.parseGroup("OR")
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.tokens) {
+		return nil, errUnbalancedFilterParens()
+	}
+
+	replacements := make(map[int]string, len(excluded))
+	root.replaceExcluded(excluded, PassQuery, replacements)
+	return replacements, nil
+}
+
+// errUnbalancedFilterParens returns the error of filter parentheses which are not balanced.
+func errUnbalancedFilterParens() error {
+	return newParamError(ParamErrorCodeInvalidValue, "filter_left_parens", "", "invalid filter parentheses: unbalanced")
+}
+
+// parseGroup parses the clauses joined by `logic`, where the clauses of an OR group are AND groups.
+func (p *filterLogicParser) parseGroup(logic string) (*filterLogicNode, error) {
+	parseChild := p.parseClause
+	if logic == "OR" {
+		parseChild = func() (*filterLogicNode, error) {
+			return p.parseGroup("AND")
+		}
+	}
+
+	group := &filterLogicNode{clause: -1, logic: logic}
+	for {
+		child, err := parseChild()
+		if err != nil {
+			return nil, err
+		}
+
+		group.children = append(group.children, child)
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].value != logic {
+			break
+		}
+
+		p.pos++
+	}
+
+	if len(group.children) == 1 {
+		return group.children[0], nil
+	}
+
+	return group, nil
+}
+
+// parseClause parses a single clause, or a group within parentheses.
+func (p *filterLogicParser) parseClause() (*filterLogicNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errUnbalancedFilterParens()
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	if token.value == "(" {
+		node, err := p.parseGroup("OR")
+		if err != nil {
+			return nil, err
+		}
+
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].value != ")" {
+			return nil, errUnbalancedFilterParens()
+		}
+
+		p.pos++
+		return node, nil
+	}
+
+	if token.clause < 0 {
+		return nil, errUnbalancedFilterParens()
+	}
+
+	return &filterLogicNode{clause: token.clause}, nil
+}
+
+// allExcluded returns true if all of the clauses of the node are excluded.
+func (n *filterLogicNode) allExcluded(excluded map[int]bool) bool {
+	if n.children == nil {
+		return excluded[n.clause]
+	}
+
+	for _, child := range n.children {
+		if !child.allExcluded(excluded) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// replaceExcluded sets the replacement of each excluded clause of the node, where `identity` is the identity of the
+// logic joining the node to the rest of the filter.
+func (n *filterLogicNode) replaceExcluded(excluded map[int]bool, identity string, replacements map[int]string) {
+	if n.allExcluded(excluded) {
+		n.replaceAll(identity, replacements)
+		return
+	}
+
+	childIdentity := PassQuery
+	if n.logic == "OR" {
+		childIdentity = FailQuery
+	}
+
+	for _, child := range n.children {
+		child.replaceExcluded(excluded, childIdentity, replacements)
+	}
+}
+
+// replaceAll sets the replacement of every clause of the node to `identity`.
+func (n *filterLogicNode) replaceAll(identity string, replacements map[int]string) {
+	if n.children == nil {
+		replacements[n.clause] = identity
+		return
+	}
+
+	for _, child := range n.children {
+		child.replaceAll(identity, replacements)
+	}
+}
+
+// getCustomFilterFacets returns the unique values and row counts for each of the provided `customColumns` from the
+// table `tableName`, after scoping said table by `scopes`, and each column by its clause in `facetClauses`.  `joins` are
+// the joins required by the facet clauses.
+//
+// In order to do this in a single query, we build a GROUPING SETS query with one grouping set per column.  Each row is
+// counted towards a facet only if it matches that facet's clause, and the query is restricted to the rows matching any
+// of the facet clauses.
+func getCustomFilterFacets(ctx context.Context, tx *pop.Connection, tableName string, customColumns CustomColumns, facetClauses []string, facetArgs [][]interface{}, joins []Join, scopes *Collection) ([]FilterFacet, error) {
+	type __stub__ struct{}
+	clauses := ""
+
+	output := make([]FilterFacet, len(customColumns))
+	if len(customColumns) == 0 {
+		return output, nil
+	}
+
+	facetScopes := NewCollection(tx)
+
+	anyFacetArgs := make([]interface{}, 0)
+	for _, args := range facetArgs {
+		anyFacetArgs = append(anyFacetArgs, args...)
+	}
+
+	facetScopes.Push(func(q *pop.Query) *pop.Query {
+		return q.Where(fmt.Sprintf("(%v)", strings.Join(facetClauses, " OR ")), anyFacetArgs...)
+	})
+
+	for _, customColumn := range customColumns {
+		joins = append(joins, customColumn.Joins...)
+	}
+
+	facetScopes.Push(ForJoins(joins...))
+
+	if scopes != nil && len(scopes.scopes) > 0 {
+		facetScopes.Push(scopes.scopes...)
+	}
+
+	scopeQueryFunc := facetScopes.Flatten()(tx.Q())
+	scopeQuerySQL, scopeQueryArgs := scopeQueryFunc.ToSQL(&pop.Model{Value: __stub__{}})
+	stubRegex := regexp.MustCompile(`^SELECT\s+FROM stubs AS stubs\s+`)
+	clauses = stubRegex.ReplaceAllString(scopeQuerySQL, "")
+
+	// Strip all order by columns out of the query, since they don't matter and will break our GROUP BY.
+	orderRegex := regexp.MustCompile(`ORDER\s+BY\s+\w+(\s+ASC|\s+DESC)?([\s,]*\w+(\s+ASC|\s+DESC)?)*`)
+	clauses = orderRegex.ReplaceAllString(clauses, "")
+
+	structFields := make([]reflect.StructField, 0, len(customColumns)*3)
+	queryStubs := make([]string, len(customColumns))
+	groupingSets := make([]string, len(customColumns))
+	orderColumns := make([]string, len(customColumns))
+	countArgs := make([]interface{}, 0)
+	for i, customColumn := range customColumns {
+		output[i] = FilterFacet{Name: customColumn.Name, Options: make([]FilterFacetOption, 0)}
+
+		// Values are scanned into pointers, since each column is null in the grouping sets of the other columns.
+		structFields = append(structFields,
+			reflect.StructField{Name: fmt.Sprintf("Value%v", i), Type: reflect.PtrTo(customColumn.ResultType), Tag: reflect.StructTag(fmt.Sprintf(`db:"value%v"`, i))},
+			reflect.StructField{Name: fmt.Sprintf("Grouping%v", i), Type: reflect.TypeOf(0), Tag: reflect.StructTag(fmt.Sprintf(`db:"grouping%v"`, i))},
+			reflect.StructField{Name: fmt.Sprintf("Count%v", i), Type: reflect.TypeOf(0), Tag: reflect.StructTag(fmt.Sprintf(`db:"count%v"`, i))},
+		)
+
+		// We never return null as a filter option, so null values are never counted.
+		queryStubs[i] = fmt.Sprintf("%v AS value%v, GROUPING(%v) AS grouping%v, COUNT(*) FILTER (WHERE %v AND %v IS NOT NULL) AS count%v", customColumn.Statement, i, customColumn.Statement, i, facetClauses[i], customColumn.Statement, i)
+		groupingSets[i] = fmt.Sprintf("(%v)", customColumn.Statement)
+		orderColumns[i] = customColumn.Statement
+		countArgs = append(countArgs, facetArgs[i]...)
+	}
+
+	typedStructArrayPtrWithDBTag := reflect.New(reflect.SliceOf(reflect.StructOf(structFields)))
+
+	generatedStatement := fmt.Sprintf("SELECT %v FROM %v %v GROUP BY GROUPING SETS (%v) ORDER BY %v", numberPlaceholders(strings.Join(queryStubs, ", "), len(scopeQueryArgs), getDialect(tx)), fromTable(getDialect(tx), tableName), clauses, strings.Join(groupingSets, ", "), strings.Join(orderColumns, ", "))
+	queryArgs := bindPlaceholderArgs(getDialect(tx), scopeQueryArgs, countArgs)
+	if err := checkQueryCost(ctx, tx, generatedStatement, queryArgs...); err != nil {
+		return nil, err
+	}
+
+	err := SimilarityError(tx.RawQuery(generatedStatement, queryArgs...).All(typedStructArrayPtrWithDBTag.Interface()))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := reflect.Indirect(typedStructArrayPtrWithDBTag)
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		for j := range customColumns {
+			// Each row belongs to the grouping set of exactly one column.
+			value, grouping, count := row.Field(j*3), row.Field(j*3+1).Int(), int(row.Field(j*3+2).Int())
+			if grouping != 0 || count == 0 || value.IsNil() {
+				continue
+			}
+
+			output[j].Options = append(output[j].Options, FilterFacetOption{Value: value.Elem().Interface(), Count: count})
+		}
+	}
+
+	// The most common values are listed first, and values with the same count are listed in order.
+	for i := range output {
+		options := output[i].Options
+		sort.SliceStable(options, func(a, b int) bool {
+			return options[a].Count > options[b].Count
+		})
+	}
+
+	return output, nil
+}
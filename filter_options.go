@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v5"
 	"github.com/pkg/errors"
 
@@ -16,6 +19,125 @@ import (
 // reflection in getCustomFilterOptions in order to be able to scan DB values into any type as needed.
 type filterOptionsQueryResult struct {
 	Result interface{} `db:"result"`
+	Label  *string     `db:"label"`
+}
+
+// FilterOptionsLimitDefault is the number of filter options returned by GetFilterOptionsFromParams when
+// `filter_options_limit` is not specified.  It is the default FilterOptionsLimitDefault of NewConfig.
+var FilterOptionsLimitDefault = 100
+
+// FilterOptionsLimitMax is the maximum number of filter options returned by GetFilterOptionsFromParams.  It is the
+// default FilterOptionsLimitMax of NewConfig.
+var FilterOptionsLimitMax = 1000
+
+type FilterOptionsOrder string
+
+const (
+	// FilterOptionsOrderValue orders filter options by their value, smallest first.
+	FilterOptionsOrderValue FilterOptionsOrder = "VALUE"
+	// FilterOptionsOrderFrequency orders filter options by the number of rows with their value, most common first.
+	FilterOptionsOrderFrequency FilterOptionsOrder = "FREQUENCY"
+)
+
+type FilterOptionsSearchMode string
+
+const (
+	// FilterOptionsSearchModePrefix matches filter options starting with the search, ignoring case.
+	FilterOptionsSearchModePrefix FilterOptionsSearchMode = "PREFIX"
+	// FilterOptionsSearchModeContains matches filter options containing the search, ignoring case.
+	FilterOptionsSearchModeContains FilterOptionsSearchMode = "CONTAINS"
+)
+
+// FilterOptionsQuery restricts and orders the filter options returned by GetFilterOptionsPage.  The zero value
+// returns every filter option, in no particular order.
+type FilterOptionsQuery struct {
+	Search     string
+	SearchMode FilterOptionsSearchMode
+	Limit      int
+	Offset     int
+	Order      FilterOptionsOrder
+}
+
+// FilterOption is a filter option of a CustomColumn with a LabelStatement or an OptionsSource.  Value is the value
+// that is filtered on, and Label is the value displayed.
+type FilterOption struct {
+	Value interface{} `json:"value"`
+	Label interface{} `json:"label"`
+}
+
+// FilterOptionsSource is a source of filter options for a CustomColumn, which is used instead of the unique values of
+// the column.  Either Options is a static list of options, ex. the values of an enum, or the options are the rows of
+// the lookup table TableName.  LabelColumn is optional.
+//
+// The filter options of a source are never restricted by scopes, since they are not read from the model's table.
+type FilterOptionsSource struct {
+	Options     []FilterOption
+	TableName   string
+	ValueColumn string
+	LabelColumn string
+}
+
+// FilterOptionsPage is a page of filter options.  HasMore is true if there are filter options after this page.
+type FilterOptionsPage struct {
+	Options []interface{} `json:"options"`
+	HasMore bool          `json:"has_more"`
+}
+
+// GetFilterOptionsFromParams returns a page of the unique values for column `filter_column` of modelsPtr based on
+// params, restricting by the scope collection scopes.
+func GetFilterOptionsFromParams(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, params buffalo.ParamValues, scopes *Collection) (*FilterOptionsPage, error) {
+	ctx, params = withParamsConfig(ctx, params, scopes)
+
+	query, err := getFilterOptionsQueryFromParams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetFilterOptionsPage(ctx, tx, modelsPtr, params.Get("filter_column"), scopes, query)
+}
+
+// getFilterOptionsQueryFromParams builds a FilterOptionsQuery from the `filter_options_*` params.  Filter options are
+// ordered by value unless specified, so that pages are stable.
+func getFilterOptionsQueryFromParams(ctx context.Context, params buffalo.ParamValues) (FilterOptionsQuery, error) {
+	config := ConfigFromContext(ctx)
+	query := FilterOptionsQuery{
+		Search:     params.Get("filter_options_search"),
+		SearchMode: FilterOptionsSearchMode(strings.ToUpper(params.Get("filter_options_search_mode"))),
+		Limit:      config.FilterOptionsLimitDefault,
+		Order:      FilterOptionsOrder(strings.ToUpper(params.Get("filter_options_order"))),
+	}
+
+	if util.IsBlank(string(query.SearchMode)) {
+		query.SearchMode = FilterOptionsSearchModePrefix
+	}
+
+	if util.IsBlank(string(query.Order)) {
+		query.Order = FilterOptionsOrderValue
+	}
+
+	if !util.IsBlank(params.Get("filter_options_limit")) {
+		limit, err := strconv.Atoi(params.Get("filter_options_limit"))
+		if err != nil || limit < 1 {
+			return query, newParamError(ParamErrorCodeInvalidValue, "filter_options_limit", params.Get("filter_options_limit"), "invalid filter options limit: %v", params.Get("filter_options_limit"))
+		}
+
+		query.Limit = limit
+	}
+
+	if query.Limit > config.FilterOptionsLimitMax {
+		query.Limit = config.FilterOptionsLimitMax
+	}
+
+	if !util.IsBlank(params.Get("filter_options_offset")) {
+		offset, err := strconv.Atoi(params.Get("filter_options_offset"))
+		if err != nil || offset < 0 {
+			return query, newParamError(ParamErrorCodeInvalidValue, "filter_options_offset", params.Get("filter_options_offset"), "invalid filter options offset: %v", params.Get("filter_options_offset"))
+		}
+
+		query.Offset = offset
+	}
+
+	return query, nil
 }
 
 // GetFilterOptions returns all of unique values for column 'columnName' of modelsPtr, restricting by the scope collection
@@ -24,6 +146,24 @@ type filterOptionsQueryResult struct {
 // 'columnName' is either a CustomColumn returned by the CustomFilterable interface, or a field specified by the json
 // tag.  This is the same as the acceptable values for 'filter_columns' in ForFiltersFromParams.
 func GetFilterOptions(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, columnName string, scopes *Collection) ([]interface{}, error) {
+	ctx = withCollectionConfig(ctx, scopes)
+
+	page, err := GetFilterOptionsPage(ctx, tx, modelsPtr, columnName, scopes, FilterOptionsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Options, nil
+}
+
+// GetFilterOptionsPage returns the unique values for column 'columnName' of modelsPtr matching `query`, restricting by
+// the scope collection scopes.
+//
+// 'columnName' is either a CustomColumn returned by the CustomFilterable interface, or a field specified by the json
+// tag.  This is the same as the acceptable values for 'filter_columns' in ForFiltersFromParams.
+func GetFilterOptionsPage(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, columnName string, scopes *Collection, query FilterOptionsQuery) (*FilterOptionsPage, error) {
+	ctx = withCollectionConfig(ctx, scopes)
+
 	v := reflect.ValueOf(modelsPtr)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
 		return nil, errors.New("pointer to slice expected")
@@ -32,6 +172,8 @@ func GetFilterOptions(ctx context.Context, tx *pop.Connection, modelsPtr interfa
 	models := v.Elem()
 	modelPtr := reflect.New(models.Type().Elem()).Interface()
 
+	// Columns are generated for the dialect of the connection.
+	ctx = WithDialect(ctx, getDialect(tx))
 	filterColumns, err := GetAllFilterColumns(ctx, modelPtr)
 	if err != nil {
 		return nil, err
@@ -46,39 +188,79 @@ func GetFilterOptions(ctx context.Context, tx *pop.Connection, modelsPtr interfa
 	}
 
 	if column == nil {
-		return nil, errors.Errorf("invalid filter field: %v", columnName)
+		return nil, newParamError(ParamErrorCodeInvalidField, "", columnName, "invalid filter field: %v", columnName)
 	}
 
 	tableName := (&pop.Model{Value: modelPtr}).TableName()
-	return getCustomFilterOptions(tx, tableName, *column, scopes)
+	return getCustomFilterOptions(ctx, tx, tableName, *column, scopes, query)
 }
 
-// getCustomFilterOptions returns all of the potential values for the provided 'customColumn' from the table 'tableName',
-// after scoping said table by 'scopes'.
+// getCustomFilterOptions returns the potential values for the provided 'customColumn' from the table 'tableName' matching
+// 'query', after scoping said table by 'scopes'.
 //
 // In order to do this, we must build a custom struct with the correct ResultType for customColumn.  We then build a
-// scoped GROUP BY query to retrieve all values for customColumn into that struct.
-func getCustomFilterOptions(tx *pop.Connection, tableName string, customColumn CustomColumn, scopes *Collection) ([]interface{}, error) {
+// scoped GROUP BY query to retrieve all values for customColumn into that struct.  If the query is ordered or limited,
+// the GROUP BY query is wrapped in a query that orders and limits the values, fetching one more value than the limit in
+// order to tell if there are more values.
+func getCustomFilterOptions(ctx context.Context, tx *pop.Connection, tableName string, customColumn CustomColumn, scopes *Collection, query FilterOptionsQuery) (*FilterOptionsPage, error) {
 	type __stub__ struct{}
 	clauses := ""
 
+	if source := customColumn.OptionsSource; source != nil {
+		if util.IsBlank(source.TableName) {
+			return getStaticFilterOptions(source.Options, query)
+		}
+
+		// Lookup tables are queried the same way as the model's table, without the model's scopes.
+		tableName = source.TableName
+		customColumn.Statement = quoteColumn(getDialect(tx), source.TableName, source.ValueColumn)
+		customColumn.LabelStatement = ""
+		if !util.IsBlank(source.LabelColumn) {
+			customColumn.LabelStatement = quoteColumn(getDialect(tx), source.TableName, source.LabelColumn)
+		}
+
+		customColumn.Joins = nil
+		scopes = nil
+	}
+
 	// We need to build a struct of type "ResultType", so that we can correctly marshall the output types from the DB.
 	templateStructField := reflect.ValueOf(filterOptionsQueryResult{}).Type().Field(0)
 	templateStructField.Type = customColumn.ResultType
 	templateStructFieldDBTag := templateStructField.Tag.Get("db")
-	typedStructWithDBTag := reflect.StructOf([]reflect.StructField{templateStructField})
+	structFields := []reflect.StructField{templateStructField}
+	groupByColumns := []string{templateStructFieldDBTag}
+
+	// Labeled values are grouped by both the value and label.
+	templateLabelStructField := reflect.ValueOf(filterOptionsQueryResult{}).Type().Field(1)
+	if !util.IsBlank(customColumn.LabelStatement) {
+		structFields = append(structFields, templateLabelStructField)
+		groupByColumns = append(groupByColumns, templateLabelStructField.Tag.Get("db"))
+	}
+
+	typedStructWithDBTag := reflect.StructOf(structFields)
 	typedStructArrayPtrWithDBTag := reflect.New(reflect.SliceOf(typedStructWithDBTag))
 	filterOptionScopes := NewCollection(tx)
 
 	// We never return null as a filter option.
-	filterOptionScopes.Push(ForNotNull(customColumn.Statement))
+	filterOptionScopes.Push(ForNotNull(customColumn.Statement), ForJoins(customColumn.Joins...))
+
+	if !util.IsBlank(query.Search) {
+		searchStatement, searchArg, err := filterOptionsSearchFor(customColumn, query, getDialect(tx))
+		if err != nil {
+			return nil, err
+		}
+
+		filterOptionScopes.Push(func(q *pop.Query) *pop.Query {
+			return q.Where(searchStatement, searchArg)
+		})
+	}
 
 	if scopes != nil && len(scopes.scopes) > 0 {
 		filterOptionScopes.Push(scopes.scopes...)
 	}
 
 	// Covert this query to a GROUP BY query in order to only get distinct results.
-	scopeQueryFunc := filterOptionScopes.Flatten()(tx.Q()).GroupBy(templateStructFieldDBTag)
+	scopeQueryFunc := filterOptionScopes.Flatten()(tx.Q()).GroupBy(groupByColumns[0], groupByColumns[1:]...)
 	scopeQuerySQL, scopeQueryArgs := scopeQueryFunc.ToSQL(&pop.Model{Value: __stub__{}})
 	stubRegex := regexp.MustCompile(`^SELECT\s+FROM stubs AS stubs\s+`)
 	clauses = stubRegex.ReplaceAllString(scopeQuerySQL, "")
@@ -87,18 +269,153 @@ func getCustomFilterOptions(tx *pop.Connection, tableName string, customColumn C
 	orderRegex := regexp.MustCompile(`ORDER\s+BY\s+\w+(\s+ASC|\s+DESC)?([\s,]*\w+(\s+ASC|\s+DESC)?)*`)
 	clauses = orderRegex.ReplaceAllString(clauses, "")
 
-	generatedStatement := fmt.Sprintf("select %v as result from %v %v", customColumn.Statement, tableName, clauses)
-	err := tx.RawQuery(generatedStatement, scopeQueryArgs...).All(typedStructArrayPtrWithDBTag.Interface())
+	generatedStatement, err := filterOptionsStatementFor(customColumn, tableName, clauses, query, getDialect(tx))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkQueryCost(ctx, tx, generatedStatement, scopeQueryArgs...); err != nil {
+		return nil, err
+	}
+
+	err = SimilarityError(tx.RawQuery(generatedStatement, scopeQueryArgs...).All(typedStructArrayPtrWithDBTag.Interface()))
 	if err != nil {
 		return nil, err
 	}
 
+	page := &FilterOptionsPage{}
 	resultLen := reflect.Indirect(typedStructArrayPtrWithDBTag).Len()
+	if query.Limit > 0 && resultLen > query.Limit {
+		page.HasMore = true
+		resultLen = query.Limit
+	}
+
 	output := reflect.MakeSlice(reflect.SliceOf(customColumn.ResultType), resultLen, resultLen)
 	for i := 0; i < resultLen; i++ {
 		value := reflect.Indirect(typedStructArrayPtrWithDBTag).Index(i).FieldByName(templateStructField.Name).Convert(customColumn.ResultType)
 		output.Index(i).Set(value)
 	}
 
-	return util.InterfaceSlice(output.Interface()), nil
+	page.Options = util.InterfaceSlice(output.Interface())
+
+	if !util.IsBlank(customColumn.LabelStatement) {
+		for i := range page.Options {
+			option := FilterOption{Value: page.Options[i]}
+			if label := reflect.Indirect(typedStructArrayPtrWithDBTag).Index(i).FieldByName(templateLabelStructField.Name); !label.IsNil() {
+				option.Label = label.Elem().Interface()
+			}
+
+			page.Options[i] = option
+		}
+	}
+
+	return page, nil
+}
+
+// getStaticFilterOptions returns the static `options` matching `query`.  Static options are always returned in the
+// order they are declared, and are searched by their label.
+func getStaticFilterOptions(options []FilterOption, query FilterOptionsQuery) (*FilterOptionsPage, error) {
+	switch FilterOptionsOrder(strings.ToUpper(string(query.Order))) {
+	case FilterOptionsOrderValue, FilterOptionsOrderFrequency, "":
+	default:
+		return nil, newParamError(ParamErrorCodeInvalidType, "filter_options_order", string(query.Order), "invalid filter options order: %v", query.Order)
+	}
+
+	search := strings.ToLower(query.Search)
+	matchedOptions := make([]interface{}, 0, len(options))
+	for _, option := range options {
+		label := option.Label
+		if label == nil {
+			label = option.Value
+		}
+
+		switch FilterOptionsSearchMode(strings.ToUpper(string(query.SearchMode))) {
+		case FilterOptionsSearchModePrefix, "":
+			if !strings.HasPrefix(strings.ToLower(fmt.Sprint(label)), search) {
+				continue
+			}
+		case FilterOptionsSearchModeContains:
+			if !strings.Contains(strings.ToLower(fmt.Sprint(label)), search) {
+				continue
+			}
+		default:
+			return nil, newParamError(ParamErrorCodeInvalidType, "filter_options_search_mode", string(query.SearchMode), "invalid filter options search mode: %v", query.SearchMode)
+		}
+
+		matchedOptions = append(matchedOptions, option)
+	}
+
+	page := &FilterOptionsPage{Options: make([]interface{}, 0)}
+	if query.Offset >= len(matchedOptions) {
+		return page, nil
+	}
+
+	matchedOptions = matchedOptions[query.Offset:]
+	if query.Limit > 0 && len(matchedOptions) > query.Limit {
+		page.HasMore = true
+		matchedOptions = matchedOptions[:query.Limit]
+	}
+
+	page.Options = matchedOptions
+	return page, nil
+}
+
+// filterOptionsSearchFor returns a WHERE clause matching the values of `customColumn` to the search of `query`, and the
+// LIKE pattern it is matched against.  Wildcards within the search are matched literally.  Labeled values are searched
+// by their label.
+func filterOptionsSearchFor(customColumn CustomColumn, query FilterOptionsQuery, dialect Dialect) (string, string, error) {
+	search := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query.Search)
+
+	statement := customColumn.Statement
+	if !util.IsBlank(customColumn.LabelStatement) {
+		statement = customColumn.LabelStatement
+	}
+
+	switch FilterOptionsSearchMode(strings.ToUpper(string(query.SearchMode))) {
+	case FilterOptionsSearchModePrefix, "":
+		return dialect.Comparison("ILK", dialect.Cast(statement, "TEXT")), search + "%", nil
+	case FilterOptionsSearchModeContains:
+		return dialect.Comparison("ILK", dialect.Cast(statement, "TEXT")), "%" + search + "%", nil
+	}
+
+	return "", "", newParamError(ParamErrorCodeInvalidType, "filter_options_search_mode", string(query.SearchMode), "invalid filter options search mode: %v", query.SearchMode)
+}
+
+// filterOptionsStatementFor returns the statement selecting the values of `customColumn` from the table `tableName`,
+// restricted by the scope `clauses`.  If `query` is ordered or limited, the statement is wrapped in a query that orders
+// and limits it.  Labeled values are ordered by their label.
+func filterOptionsStatementFor(customColumn CustomColumn, tableName, clauses string, query FilterOptionsQuery, dialect Dialect) (string, error) {
+	resultColumns := "result"
+	selectStatement := fmt.Sprintf("%v as result", customColumn.Statement)
+	valueOrderClause := "result ASC"
+	if !util.IsBlank(customColumn.LabelStatement) {
+		resultColumns = "result, label"
+		selectStatement = fmt.Sprintf("%v, %v as label", selectStatement, dialect.Cast(customColumn.LabelStatement, "TEXT"))
+		valueOrderClause = "label ASC, result ASC"
+	}
+
+	if util.IsBlank(string(query.Order)) && query.Limit == 0 && query.Offset == 0 {
+		return fmt.Sprintf("select %v from %v %v", selectStatement, fromTable(dialect, tableName), clauses), nil
+	}
+
+	orderClause := ""
+	switch FilterOptionsOrder(strings.ToUpper(string(query.Order))) {
+	case FilterOptionsOrderValue:
+		orderClause = fmt.Sprintf("ORDER BY %v", valueOrderClause)
+	case FilterOptionsOrderFrequency:
+		orderClause = fmt.Sprintf("ORDER BY frequency DESC, %v", valueOrderClause)
+	case "":
+	default:
+		return "", newParamError(ParamErrorCodeInvalidType, "filter_options_order", string(query.Order), "invalid filter options order: %v", query.Order)
+	}
+
+	limitClause := ""
+	if query.Limit > 0 {
+		// We fetch one more value than the limit, so that we can tell if there are more values.
+		limitClause = fmt.Sprintf("LIMIT %v", query.Limit+1)
+	}
+
+	// The frequency of each value is counted by the GROUP BY of the scope clauses.
+	statement := fmt.Sprintf("select %v, COUNT(*) as frequency from %v %v", selectStatement, fromTable(dialect, tableName), clauses)
+	return fmt.Sprintf("select %v from (%v) as filter_options %v %v OFFSET %v", resultColumns, statement, orderClause, limitClause, query.Offset), nil
 }
@@ -2,6 +2,8 @@ package scope_test
 
 import (
 	"context"
+	"encoding/json"
+	"net/url"
 	"reflect"
 
 	"github.com/gobuffalo/nulls"
@@ -25,17 +27,17 @@ func (t TestObject) TableName() string {
 func (t TestObject) GetCustomFilters(ctx context.Context) scope.CustomColumns {
 	customFilter := scope.CustomColumn{
 		Name:       "custom_filter",
-		Statement:  `(SELECT '1234'::TEXT)`,
+		Statement:  `(SELECT CAST('1234' AS TEXT))`,
 		ResultType: reflect.TypeOf("1234"),
 	}
 	customUUIDFilter := scope.CustomColumn{
 		Name:       "custom_uuid_filter",
-		Statement:  `(SELECT uuid_nil())`,
+		Statement:  `(SELECT '00000000-0000-0000-0000-000000000000')`,
 		ResultType: reflect.TypeOf(uuid.UUID{}),
 	}
 	customNullsUUIDFilter := scope.CustomColumn{
 		Name:       "custom_nulls_uuid_filter",
-		Statement:  `(SELECT uuid_nil())`,
+		Statement:  `(SELECT '00000000-0000-0000-0000-000000000000')`,
 		ResultType: reflect.TypeOf(nulls.UUID{}),
 	}
 	nullFilter := scope.CustomColumn{
@@ -49,17 +51,17 @@ func (t TestObject) GetCustomFilters(ctx context.Context) scope.CustomColumns {
 func (t TestObject) GetCustomSorts(ctx context.Context) scope.CustomColumns {
 	customSort := scope.CustomColumn{
 		Name:       "custom_sort",
-		Statement:  `(SELECT '1234'::TEXT)`,
+		Statement:  `(SELECT CAST('1234' AS TEXT))`,
 		ResultType: reflect.TypeOf("1234"),
 	}
 	customUUIDSort := scope.CustomColumn{
 		Name:       "custom_uuid_sort",
-		Statement:  `(SELECT uuid_nil())`,
+		Statement:  `(SELECT '00000000-0000-0000-0000-000000000000')`,
 		ResultType: reflect.TypeOf(uuid.UUID{}),
 	}
 	customNullsUUIDSort := scope.CustomColumn{
 		Name:       "custom_nulls_uuid_sort",
-		Statement:  `(SELECT uuid_nil())`,
+		Statement:  `(SELECT '00000000-0000-0000-0000-000000000000')`,
 		ResultType: reflect.TypeOf(nulls.UUID{}),
 	}
 	nullSort := scope.CustomColumn{
@@ -213,17 +215,17 @@ func (t *TestObjectPtrTablenames) TableName() string {
 func (t TestObjectPtrTablename) GetCustomFilters(ctx context.Context) scope.CustomColumns {
 	customFilter := scope.CustomColumn{
 		Name:       "custom_filter",
-		Statement:  `(SELECT '1234'::TEXT)`,
+		Statement:  `(SELECT CAST('1234' AS TEXT))`,
 		ResultType: reflect.TypeOf("1234"),
 	}
 	customUUIDFilter := scope.CustomColumn{
 		Name:       "custom_uuid_filter",
-		Statement:  `(SELECT uuid_nil())`,
+		Statement:  `(SELECT '00000000-0000-0000-0000-000000000000')`,
 		ResultType: reflect.TypeOf(uuid.UUID{}),
 	}
 	customNullsUUIDFilter := scope.CustomColumn{
 		Name:       "custom_nulls_uuid_filter",
-		Statement:  `(SELECT uuid_nil())`,
+		Statement:  `(SELECT '00000000-0000-0000-0000-000000000000')`,
 		ResultType: reflect.TypeOf(nulls.UUID{}),
 	}
 	nullFilter := scope.CustomColumn{
@@ -237,17 +239,17 @@ func (t TestObjectPtrTablename) GetCustomFilters(ctx context.Context) scope.Cust
 func (t TestObjectPtrTablename) GetCustomSorts(ctx context.Context) scope.CustomColumns {
 	customSort := scope.CustomColumn{
 		Name:       "custom_sort",
-		Statement:  `(SELECT '1234'::TEXT)`,
+		Statement:  `(SELECT CAST('1234' AS TEXT))`,
 		ResultType: reflect.TypeOf("1234"),
 	}
 	customUUIDSort := scope.CustomColumn{
 		Name:       "custom_uuid_sort",
-		Statement:  `(SELECT uuid_nil())`,
+		Statement:  `(SELECT '00000000-0000-0000-0000-000000000000')`,
 		ResultType: reflect.TypeOf(uuid.UUID{}),
 	}
 	customNullsUUIDSort := scope.CustomColumn{
 		Name:       "custom_nulls_uuid_sort",
-		Statement:  `(SELECT uuid_nil())`,
+		Statement:  `(SELECT '00000000-0000-0000-0000-000000000000')`,
 		ResultType: reflect.TypeOf(nulls.UUID{}),
 	}
 	nullSort := scope.CustomColumn{
@@ -380,3 +382,249 @@ func (ss *ScopesSuite) TestGetFilterOptions_withCustomNullResult_ptrTablename()
 	ss.NoError(err)
 	ss.Equal([]interface{}{}, filterOptions)
 }
+
+func (ss *ScopesSuite) createFilterOptionsObjects() {
+	for _, number := range []float64{1, 1, 1, 2, 2, 3, 12} {
+		testObject := &TestObject{ID: uuid.Must(uuid.NewV4()), Number: number}
+		err := ss.DB.Create(testObject)
+		ss.NoError(err)
+	}
+}
+
+func (ss *ScopesSuite) TestGetFilterOptionsFromParams() {
+	ss.createFilterOptionsObjects()
+
+	params := map[string][]string{
+		"filter_column":        {"num"},
+		"filter_options_limit": {"2"},
+	}
+
+	page, err := scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{float64(1), float64(2)}, HasMore: true}, page)
+
+	params["filter_options_offset"] = []string{"2"}
+	page, err = scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{float64(3), float64(12)}, HasMore: false}, page)
+
+	jsn, err := json.Marshal(page)
+	ss.NoError(err)
+	ss.Equal(`{"options":[3,12],"has_more":false}`, string(jsn))
+}
+
+func (ss *ScopesSuite) TestGetFilterOptionsFromParams_frequency() {
+	ss.createFilterOptionsObjects()
+
+	params := map[string][]string{
+		"filter_column":        {"num"},
+		"filter_options_order": {"frequency"},
+		"filter_options_limit": {"3"},
+	}
+
+	page, err := scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{float64(1), float64(2), float64(3)}, HasMore: true}, page)
+}
+
+func (ss *ScopesSuite) TestGetFilterOptionsFromParams_search() {
+	ss.createFilterOptionsObjects()
+
+	params := map[string][]string{
+		"filter_column":         {"num"},
+		"filter_options_search": {"1"},
+	}
+
+	page, err := scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{float64(1), float64(12)}}, page)
+
+	params["filter_options_search"] = []string{"2"}
+	params["filter_options_search_mode"] = []string{"contains"}
+	page, err = scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{float64(2), float64(12)}}, page)
+
+	// Wildcards are matched literally.
+	params["filter_options_search"] = []string{"%"}
+	page, err = scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{}}, page)
+}
+
+// Wildcards are escaped, so they must be matched literally with every dialect, ex. SQLite, which has no default escape
+// character.
+func (ss *ScopesSuite) TestGetFilterOptionsFromParams_searchWildcards() {
+	for _, title := range []string{"50% off", "500 off", "a_b", "axb"} {
+		ss.NoError(ss.DB.Create(&TestDocument{ID: uuid.Must(uuid.NewV4()), Title: title}))
+	}
+
+	testCases := map[string][]interface{}{
+		"50%": {"50% off"},
+		"a_b": {"a_b"},
+		"_":   {"a_b"},
+	}
+
+	for search, expected := range testCases {
+		params := url.Values{
+			"filter_column":              {"title"},
+			"filter_options_search":      {search},
+			"filter_options_search_mode": {"contains"},
+		}
+
+		page, err := scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestDocument{}, params, nil)
+		ss.NoError(err)
+		ss.Equal(&scope.FilterOptionsPage{Options: expected}, page, search)
+	}
+}
+
+func (ss *ScopesSuite) TestGetFilterOptionsPage_withScopes() {
+	ss.createFilterOptionsObjects()
+
+	scopes := scope.NewCollection(ss.DB)
+	scopes.Push(scope.ForOrder("num DESC"))
+	scopes.Push(scope.ForLimit(4))
+
+	query := scope.FilterOptionsQuery{Order: scope.FilterOptionsOrderValue, Limit: 1}
+	page, err := scope.GetFilterOptionsPage(context.Background(), ss.DB, &[]TestObject{}, "num", scopes, query)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{float64(1)}, HasMore: true}, page)
+}
+
+func (ss *ScopesSuite) TestGetFilterOptionsFromParams_invalid() {
+	testCases := []map[string][]string{
+		{"filter_column": {"not_in_db"}},
+		{"filter_column": {"num"}, "filter_options_limit": {"abc"}},
+		{"filter_column": {"num"}, "filter_options_limit": {"0"}},
+		{"filter_column": {"num"}, "filter_options_offset": {"-1"}},
+		{"filter_column": {"num"}, "filter_options_order": {"random"}},
+		{"filter_column": {"num"}, "filter_options_search": {"1"}, "filter_options_search_mode": {"fuzzy"}},
+	}
+
+	for _, params := range testCases {
+		_, err := scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+		ss.Error(err)
+	}
+}
+
+type TestLabeledObject struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	Number float64   `json:"num" db:"num"`
+}
+
+func (t TestLabeledObject) TableName() string {
+	return "objects"
+}
+
+func (t TestLabeledObject) GetCustomFilters(ctx context.Context) scope.CustomColumns {
+	labeledFilter := scope.CustomColumn{
+		Name:           "labeled_num",
+		Statement:      "objects.num",
+		ResultType:     reflect.TypeOf(float64(0)),
+		LabelStatement: "'#' || CAST(objects.num AS TEXT)",
+	}
+	staticFilter := scope.CustomColumn{
+		Name:       "static_num",
+		Statement:  "objects.num",
+		ResultType: reflect.TypeOf(float64(0)),
+		OptionsSource: &scope.FilterOptionsSource{
+			Options: []scope.FilterOption{{Value: 3, Label: "Three"}, {Value: 2, Label: "Two"}, {Value: 1, Label: "One"}},
+		},
+	}
+	lookupFilter := scope.CustomColumn{
+		Name:       "lookup_id",
+		Statement:  "objects.id",
+		ResultType: reflect.TypeOf(uuid.UUID{}),
+		OptionsSource: &scope.FilterOptionsSource{
+			TableName:   "objects",
+			ValueColumn: "id",
+			LabelColumn: "num",
+		},
+	}
+	return scope.CustomColumns{labeledFilter, staticFilter, lookupFilter}
+}
+
+func (ss *ScopesSuite) TestGetFilterOptionsFromParams_labeled() {
+	ss.createFilterOptionsObjects()
+
+	params := map[string][]string{
+		"filter_column":        {"labeled_num"},
+		"filter_options_limit": {"3"},
+	}
+
+	page, err := scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestLabeledObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{
+		Options: []interface{}{
+			scope.FilterOption{Value: float64(1), Label: "#1"},
+			scope.FilterOption{Value: float64(12), Label: "#12"},
+			scope.FilterOption{Value: float64(2), Label: "#2"},
+		},
+		HasMore: true,
+	}, page)
+
+	jsn, err := json.Marshal(page.Options[0])
+	ss.NoError(err)
+	ss.Equal(`{"value":1,"label":"#1"}`, string(jsn))
+
+	// Labeled values are searched by their label.
+	params["filter_options_search"] = []string{"#3"}
+	page, err = scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestLabeledObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{scope.FilterOption{Value: float64(3), Label: "#3"}}}, page)
+}
+
+func (ss *ScopesSuite) TestGetFilterOptions_labeled() {
+	ss.createFilterOptionsObjects()
+
+	filterOptions, err := scope.GetFilterOptions(context.Background(), ss.DB, &[]TestLabeledObject{}, "labeled_num", nil)
+	ss.NoError(err)
+	ss.ElementsMatch([]interface{}{
+		scope.FilterOption{Value: float64(1), Label: "#1"},
+		scope.FilterOption{Value: float64(2), Label: "#2"},
+		scope.FilterOption{Value: float64(3), Label: "#3"},
+		scope.FilterOption{Value: float64(12), Label: "#12"},
+	}, filterOptions)
+}
+
+func (ss *ScopesSuite) TestGetFilterOptionsFromParams_staticSource() {
+	params := map[string][]string{
+		"filter_column":        {"static_num"},
+		"filter_options_limit": {"2"},
+	}
+
+	// Static options do not depend on the rows of the table.
+	page, err := scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestLabeledObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{
+		Options: []interface{}{scope.FilterOption{Value: 3, Label: "Three"}, scope.FilterOption{Value: 2, Label: "Two"}},
+		HasMore: true,
+	}, page)
+
+	params["filter_options_search"] = []string{"t"}
+	params["filter_options_offset"] = []string{"1"}
+	page, err = scope.GetFilterOptionsFromParams(context.Background(), ss.DB, &[]TestLabeledObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(&scope.FilterOptionsPage{Options: []interface{}{scope.FilterOption{Value: 2, Label: "Two"}}}, page)
+}
+
+func (ss *ScopesSuite) TestGetFilterOptions_tableSource() {
+	testObject := &TestLabeledObject{ID: uuid.Must(uuid.NewV4()), Number: 1}
+	err := ss.DB.Create(testObject)
+	ss.NoError(err)
+
+	testObject2 := &TestLabeledObject{ID: uuid.Must(uuid.NewV4()), Number: 2}
+	err = ss.DB.Create(testObject2)
+	ss.NoError(err)
+
+	// Lookup tables are not restricted by scopes.
+	scopes := scope.NewCollection(ss.DB)
+	scopes.Push(scope.ForUuidID(testObject.ID))
+
+	filterOptions, err := scope.GetFilterOptions(context.Background(), ss.DB, &[]TestLabeledObject{}, "lookup_id", scopes)
+	ss.NoError(err)
+	ss.ElementsMatch([]interface{}{
+		scope.FilterOption{Value: testObject.ID, Label: "1"},
+		scope.FilterOption{Value: testObject2.ID, Label: "2"},
+	}, filterOptions)
+}
@@ -2,6 +2,7 @@ package scope
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -15,6 +16,7 @@ import (
 )
 
 const FailQuery = "1=0"
+const PassQuery = "1=1"
 
 /*************** Generic Filtering and Sorting ****************/
 // Filter operands that can be used within ForFiltersFromParams
@@ -35,6 +37,14 @@ var filterTypes = map[string]string{
 	"NDF":  "is not distinct from",
 	"IN":   "in",
 	"NIN":  "not in",
+	"SIM":  "similarity",
+	"CT":   "@>",
+	"HK":   "jsonb_exists",
+
+	"CONTAINS":     "@>",
+	"CONTAINED_BY": "<@",
+	"OVERLAPS":     "&&",
+	"ANY":          "= ANY",
 }
 
 // Filter logics that can be used within ForFiltersFromParams
@@ -47,18 +57,60 @@ var filterLogics = map[string]string{
 var sortDirections = map[string]string{
 	"ASC":  "ASC",
 	"DESC": "DESC",
+	"SIM":  "DESC",
 }
 
 // ForFiltersFromParams filters a model based on the provided filter params.
 func ForFiltersFromParams(ctx context.Context, model interface{}, params buffalo.ParamValues) (pop.ScopeFunc, error) {
+	ctx, params = withParamsConfig(ctx, params, nil)
+
+	dialect := DialectFromContext(ctx)
+	queryString, args, joins, err := getFilterClauseFromParams(ctx, model, params, nil, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	// If nothing is specified, this is a no-op.
+	if util.IsBlank(queryString) {
+		return func(q *pop.Query) *pop.Query {
+			return q
+		}, nil
+	}
+
+	return func(q *pop.Query) *pop.Query {
+		// The clause is built again for any other dialect of the query.  Scopes cannot return errors, so a filter that
+		// the dialect does not support matches no rows, see GetDialect.
+		if queryDialect := getDialect(q.Connection); queryDialect != dialect {
+			dialectQueryString, dialectArgs, dialectJoins, err := getFilterClauseFromParams(ctx, model, params, nil, queryDialect)
+			if err != nil {
+				return q.Where("1 = 0")
+			}
+
+			return ForJoins(dialectJoins...)(q).Where(dialectQueryString, dialectArgs...)
+		}
+
+		return ForJoins(joins...)(q).Where(queryString, args...)
+	}, nil
+}
+
+// getFilterClauseFromParams builds the WHERE clause, its args and the joins it requires for the provided filter params.
+// An empty clause is returned if no filters are specified.
+//
+// Any filters on a column in `excludedColumns` are removed from the filter logic, by replacing them with PassQuery
+// within AND and FailQuery within OR, so that they no longer restrict that column.
+// Filter types and features that `dialect` does not support return an error.
+func getFilterClauseFromParams(ctx context.Context, model interface{}, params buffalo.ParamValues, excludedColumns map[string]bool, dialect Dialect) (string, []interface{}, []Join, error) {
 	v := reflect.ValueOf(model)
 	if v.Kind() != reflect.Struct {
-		return nil, errors.New("struct expected")
+		return "", nil, nil, errors.New("struct expected")
 	}
 	modelPtr := reflect.New(reflect.TypeOf(model)).Interface()
 
-	filterSeparator := getFilterSeparator(params)
-	filterArgsSeparator := getFilterArgsSeparator(params)
+	// Columns are generated for the dialect, including custom columns.
+	ctx = WithDialect(ctx, dialect)
+
+	filterSeparator := getFilterSeparator(ctx, params)
+	filterArgsSeparator := getFilterArgsSeparator(ctx, params)
 
 	columns := make([]string, 0)
 	if !util.IsBlank(params.Get("filter_columns")) {
@@ -80,60 +132,177 @@ func ForFiltersFromParams(ctx context.Context, model interface{}, params buffalo
 	if !util.IsBlank(params.Get("filter_logic")) {
 		logic = strings.Split(params.Get("filter_logic"), filterSeparator)
 	}
+	quantifiers := make([]string, 0)
+	if !util.IsBlank(params.Get("filter_quantifiers")) {
+		quantifiers = strings.Split(params.Get("filter_quantifiers"), filterSeparator)
+	}
 
 	// filter_values can be empty and still be valid (for example, X = "")
 	values := strings.Split(params.Get("filter_values"), filterSeparator)
 
 	// If nothing is specified, this is a no-op.
 	if len(columns) == 0 && len(types) == 0 && len(values) == 1 && len(logic) == 0 && len(leftParens) == 0 && len(rightParens) == 0 {
-		return func(q *pop.Query) *pop.Query {
-			return q
-		}, nil
+		return "", nil, nil, nil
 	}
 
-	if len(columns) != len(types) || len(columns) != len(values) || len(columns) != len(logic)+1 || len(leftParens) != len(rightParens) {
+	if len(columns) != len(types) || len(columns) != len(values) || len(columns) != len(logic)+1 || len(leftParens) != len(rightParens) || (len(quantifiers) > 0 && len(columns) != len(quantifiers)) {
 		// We must have the same number of all filtering params.  We must have 1 more column than logical operators.
-		return nil, errors.New("missing or mismatched filter parameters")
+		// Quantifiers are only needed for relation columns.
+		return "", nil, nil, newParamError(ParamErrorCodeMismatchedParams, "", "", "missing or mismatched filter parameters")
+	}
+
+	limits := LimitsFromContext(ctx)
+	if exceeds(len(columns), limits.MaxFilterClauses) {
+		return "", nil, nil, newParamError(ParamErrorCodeLimitExceeded, "filter_columns", "", "too many filter clauses: more than %v", limits.MaxFilterClauses)
+	}
+
+	similarityThreshold, err := getFilterSimilarityThreshold(ctx, params)
+	if err != nil {
+		return "", nil, nil, err
 	}
 
 	// Check for custom filter fields, and handle appropriately.
 	columnMap := make(map[string]string, 0)
+	columnTypes := make(map[string]reflect.Type, 0)
+	columnJoins := make(map[string][]Join, 0)
 	filterColumns, err := GetAllFilterColumns(ctx, modelPtr)
 	if err != nil {
-		return nil, err
+		return "", nil, nil, err
 	}
 
 	for _, column := range filterColumns {
 		columnMap[column.Name] = column.Statement
+		columnTypes[column.Name] = column.ResultType
+		columnJoins[column.Name] = column.Joins
 	}
 
+	metadata, err := getModelMetadata(modelPtr, dialect)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	pathColumnNames := metadata.pathColumnNames
+
 	clauses := make([]string, len(columns))
+	relationPrefixes := make([]string, len(columns))
+	relationSuffixes := make([]string, len(columns))
+	excludedClauses := make(map[int]bool)
 	args := make([]interface{}, 0)
+	joins := make([]Join, 0)
 	argsPerClause := make([]int, len(columns))
 	for i, col := range columns {
 		// Find the correct operator for this filter.
 		op, ok := filterTypes[strings.ToUpper(types[i])]
 		if !ok {
-			return nil, errors.Errorf("invalid filter type: %v", types[i])
+			return "", nil, nil, newClauseError(ParamErrorCodeInvalidType, "filter_types", i, types[i], "invalid filter type: %v", types[i])
+		} else if !dialect.Supports(types[i]) {
+			return "", nil, nil, newClauseError(ParamErrorCodeUnsupported, "filter_types", i, types[i], "invalid filter type: %v is not supported by %v", types[i], dialect.Name())
 		}
 
-		// If this column is filterable, build this clause.
-		if stmt, ok := columnMap[col]; ok {
-			clauses[i] = fmt.Sprintf("%s %s", stmt, op)
+		// If this column is filterable, build this clause.  Columns may also be a path within a JSONB column, or a
+		// column of a relation.
+		stmt, ok := columnMap[col]
+		resultType := columnTypes[col]
+		if !ok {
+			stmt, ok, err = getJSONPathStatement(col, columnMap, pathColumnNames, types[i])
+			if err != nil {
+				return "", nil, nil, atClause(err, "filter_columns", i)
+			} else if ok && !dialect.Supports(FeatureJSONPaths) {
+				return "", nil, nil, newClauseError(ParamErrorCodeUnsupported, "filter_columns", i, col, "invalid filter field: %v, JSON paths are not supported by %v", col, dialect.Name())
+			}
+		}
+
+		var relationColumn *relationFilterColumn
+		if !ok {
+			relationColumn, err = getRelationFilterColumn(ctx, model, col)
+			if err != nil {
+				return "", nil, nil, err
+			}
+
+			if relationColumn != nil {
+				stmt, resultType, ok = relationColumn.Statement, relationColumn.ResultType, true
+			}
+		}
+
+		if !ok {
+			return "", nil, nil, newClauseError(ParamErrorCodeInvalidField, "filter_columns", i, col, "invalid filter field: %v", col)
+		} else if filterOperatorHasInlineArg(types[i]) {
+			clauses[i] = buildInlineArgFilterClause(stmt, types[i], op, similarityThreshold)
+		} else if filterOperatorHasOneArg(types[i]) {
+			clauses[i] = dialect.Comparison(types[i], stmt)
 		} else {
-			return nil, errors.Errorf("invalid filter field: %v", col)
+			clauses[i] = fmt.Sprintf("%s %s", stmt, op)
 		}
 
-		// Add the arg to the list if this operator takes args.
-		if filterOperatorHasOneArg(types[i]) {
+		// Array operators are only valid for columns with array results.
+		if filterOperatorIsArray(types[i]) && !isArrayType(resultType) {
+			return "", nil, nil, newClauseError(ParamErrorCodeInvalidType, "filter_types", i, types[i], "invalid filter type: %v requires an array field", types[i])
+		}
+
+		// Containment is only meaningful for valid JSON, which we check here for a clearer error than the DB's.
+		if strings.ToUpper(types[i]) == "CT" && !json.Valid([]byte(values[i])) {
+			return "", nil, nil, newClauseError(ParamErrorCodeInvalidValue, "filter_values", i, values[i], "invalid filter value: %v is not valid JSON", values[i])
+		}
+
+		// Leading wildcards can't use an index, so they may be rejected.
+		if limits.RejectLeadingWildcards && hasLeadingWildcard(types[i], values[i]) {
+			return "", nil, nil, newClauseError(ParamErrorCodeLimitExceeded, "filter_values", i, values[i], "invalid filter value: %v has a leading wildcard", values[i])
+		}
+
+		// Excluded columns still need to be valid, but are removed from the filter logic once it is parsed.
+		if excludedColumns[col] {
+			clauses[i] = PassQuery
+			excludedClauses[i] = true
+			continue
+		}
+
+		joins = append(joins, columnJoins[col]...)
+
+		// Clauses on the columns of a relation are quantified over the related rows.
+		quantifier := ""
+		if len(quantifiers) > 0 {
+			quantifier = strings.TrimSpace(quantifiers[i])
+		}
+
+		if relationColumn != nil && !relationColumn.IsCount {
+			relationPrefixes[i], relationSuffixes[i], err = relationColumn.quantify(quantifier)
+			if err != nil {
+				return "", nil, nil, atClause(err, "filter_quantifiers", i)
+			}
+		} else if quantifier != "" {
+			return "", nil, nil, newClauseError(ParamErrorCodeUnsupported, "filter_quantifiers", i, quantifier, "invalid filter quantifier: %v requires a relation field", quantifier)
+		}
+
+		// Add the arg to the list if this operator takes args.  Array operators take all of their args as one array.
+		if filterOperatorIsArray(types[i]) && !filterOperatorHasInlineArg(types[i]) {
+			separatedValues := make([]string, 0)
+			if !util.IsBlank(values[i]) {
+				separatedValues = strings.Split(values[i], filterArgsSeparator)
+			}
+
+			if exceeds(len(separatedValues), limits.MaxFilterValues) {
+				return "", nil, nil, newClauseError(ParamErrorCodeLimitExceeded, "filter_values", i, values[i], "too many filter values: %v has more than %v values", col, limits.MaxFilterValues)
+			}
+
+			args = append(args, getArrayLiteral(separatedValues))
+			argsPerClause[i] = 1
+		} else if filterOperatorHasOneArg(types[i]) {
 			args = append(args, values[i])
 			argsPerClause[i] = 1
+
+			// Similarity filters using the `%` operator compare their value twice.
+			if strings.ToUpper(types[i]) == "SIM" && similarityFilterUsesOperator(similarityThreshold) {
+				args = append(args, values[i])
+			}
 		} else if filterOperatorHasArgs(types[i]) {
 			if util.IsBlank(values[i]) {
 				argsPerClause[i] = 0
 			} else {
 
 				separatedValues := strings.Split(values[i], filterArgsSeparator)
+				if exceeds(len(separatedValues), limits.MaxFilterValues) {
+					return "", nil, nil, newClauseError(ParamErrorCodeLimitExceeded, "filter_values", i, values[i], "too many filter values: %v has more than %v values", col, limits.MaxFilterValues)
+				}
+
 				for _, arg := range separatedValues {
 					args = append(args, arg)
 				}
@@ -145,47 +314,60 @@ func ForFiltersFromParams(ctx context.Context, model interface{}, params buffalo
 	// Convert parenthesis into appropriate strings.
 	leftParenIndicies := make(map[int]string, 0)
 	rightParenIndicies := make(map[int]string, 0)
-	for _, i := range leftParens {
+	for j, i := range leftParens {
 		index, err := strconv.Atoi(i)
 		if index > len(columns)-1 || err != nil {
-			return nil, errors.Errorf("invalid filter parentheses: %v", i)
+			return "", nil, nil, newClauseError(ParamErrorCodeInvalidValue, "filter_left_parens", j, i, "invalid filter parentheses: %v", i)
 		}
 		leftParenIndicies[index] = leftParenIndicies[index] + "("
 	}
 
-	for _, i := range rightParens {
+	for j, i := range rightParens {
 		index, err := strconv.Atoi(i)
 		if index > len(columns)-1 || err != nil {
-			return nil, errors.Errorf("invalid filter parentheses: %v", i)
+			return "", nil, nil, newClauseError(ParamErrorCodeInvalidValue, "filter_right_parens", j, i, "invalid filter parentheses: %v", i)
 		}
 		rightParenIndicies[index] = rightParenIndicies[index] + ")"
 	}
 
+	if exceeds(filterParenDepth(len(columns), leftParenIndicies, rightParenIndicies), limits.MaxFilterParenDepth) {
+		return "", nil, nil, newParamError(ParamErrorCodeLimitExceeded, "filter_left_parens", "", "too many nested filter parentheses: more than %v", limits.MaxFilterParenDepth)
+	}
+
+	// Excluded clauses are replaced by the identity of their logic, which is only PassQuery within AND.
+	if len(excludedClauses) > 0 {
+		replacements, err := getExcludedFilterClauses(excludedClauses, len(columns), logic, leftParenIndicies, rightParenIndicies)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		for i, clause := range replacements {
+			clauses[i] = clause
+		}
+	}
+
 	// Apply Logic, starting with the first clause.
-	queryString := buildFilterClause(clauses[0], types[0], argsPerClause[0], leftParenIndicies[0], rightParenIndicies[0])
+	queryString := buildFilterClause(clauses[0], types[0], argsPerClause[0], leftParenIndicies[0]+relationPrefixes[0], relationSuffixes[0]+rightParenIndicies[0])
 	for i, l := range logic {
 		logic, ok := filterLogics[strings.ToUpper(l)]
 		if !ok {
-			return nil, errors.Errorf("invalid filter logic: %v", logic[i])
+			return "", nil, nil, newClauseError(ParamErrorCodeInvalidType, "filter_logic", i, l, "invalid filter logic: %v", l)
 		}
 
-		clauseWithArgs := buildFilterClause(clauses[i+1], types[i+1], argsPerClause[i+1], leftParenIndicies[i+1], rightParenIndicies[i+1])
+		clauseWithArgs := buildFilterClause(clauses[i+1], types[i+1], argsPerClause[i+1], leftParenIndicies[i+1]+relationPrefixes[i+1], relationSuffixes[i+1]+rightParenIndicies[i+1])
 		queryString = fmt.Sprintf("%s %s %s", queryString, logic, clauseWithArgs)
 	}
 
 	// Wrap our query string in parens, so its always evaluated as 1 expression and cannot conflict with other scopes.
 	queryString = fmt.Sprintf("(%s)", queryString)
 
-	return func(q *pop.Query) *pop.Query {
-		return q.Where(queryString, args...)
-	}, nil
+	return queryString, args, joins, nil
 }
 
 func buildFilterClause(clause, operator string, argsPerClause int, leftParen, rightParen string) string {
-	if filterOperatorHasNoArgs(operator) {
+	// Clauses with one arg already contain its placeholder, see Dialect.Comparison.
+	if !filterOperatorHasArgs(operator) || clause == PassQuery || clause == FailQuery {
 		return fmt.Sprintf("%s%s%s", leftParen, clause, rightParen)
-	} else if filterOperatorHasOneArg(operator) {
-		return fmt.Sprintf("%s%s ?%s", leftParen, clause, rightParen)
 	}
 
 	if argsPerClause == 0 {
@@ -205,6 +387,35 @@ func filterOperatorHasOneArg(operator string) bool {
 	return !(filterOperatorHasNoArgs(operator) || filterOperatorHasArgs(operator))
 }
 
+// filterOperatorHasInlineArg returns true for operators whose clause already contains the placeholder for their one arg.
+func filterOperatorHasInlineArg(operator string) bool {
+	return strings.ToUpper(operator) == "SIM" || strings.ToUpper(operator) == "ANY" || filterOperatorIsJSON(operator)
+}
+
+// filterOperatorIsJSON returns true for operators that compare JSONB values rather than text values.
+func filterOperatorIsJSON(operator string) bool {
+	return strings.ToUpper(operator) == "CT" || strings.ToUpper(operator) == "HK"
+}
+
+// buildInlineArgFilterClause builds the clause of an operator that has an inline arg.  Key existence is checked with
+// jsonb_exists rather than the `?` operator, since `?` is the placeholder of args.  Similarity filters use the pg_trgm
+// `%` operator where they can, so that they can use a trigram index.
+func buildInlineArgFilterClause(stmt, operator, op string, similarityThreshold float64) string {
+	switch strings.ToUpper(operator) {
+	case "SIM":
+		if similarityFilterUsesOperator(similarityThreshold) {
+			return fmt.Sprintf("(CAST(%s AS TEXT) %% ? AND %s(CAST(%s AS TEXT), ?) >= %v)", stmt, op, stmt, similarityThreshold)
+		}
+		return fmt.Sprintf("%s(CAST(%s AS TEXT), ?) >= %v", op, stmt, similarityThreshold)
+	case "CT":
+		return fmt.Sprintf("%s %s CAST(? AS JSONB)", stmt, op)
+	case "ANY":
+		return fmt.Sprintf("? %s(%s)", op, stmt)
+	default:
+		return fmt.Sprintf("%s(%s, ?)", op, stmt)
+	}
+}
+
 func filterOperatorHasArgs(operator string) bool {
 	return strings.ToUpper(operator) == "IN" || strings.ToUpper(operator) == "NIN"
 }
@@ -221,13 +432,47 @@ func ForOrder(orderClauses ...string) pop.ScopeFunc {
 
 // ForSortFromParams orders a query based on the provided query params.
 func ForSortFromParams(ctx context.Context, model interface{}, params buffalo.ParamValues) (pop.ScopeFunc, error) {
+	ctx, params = withParamsConfig(ctx, params, nil)
+
+	dialect := DialectFromContext(ctx)
+	clauses, clauseArgs, joins, err := getSortClausesFromParams(ctx, model, params, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(q *pop.Query) *pop.Query {
+		// The clauses are built again for any other dialect of the query.  Scopes cannot return errors, so a query with a
+		// sort that the dialect does not support matches no rows, see GetDialect.
+		if queryDialect := getDialect(q.Connection); queryDialect != dialect {
+			dialectClauses, dialectClauseArgs, dialectJoins, err := getSortClausesFromParams(ctx, model, params, queryDialect)
+			if err != nil {
+				return q.Where("1 = 0")
+			}
+
+			clauses, clauseArgs, joins = dialectClauses, dialectClauseArgs, dialectJoins
+		}
+
+		q = ForJoins(joins...)(q)
+		for i, clause := range clauses {
+			q.Order(clause, clauseArgs[i]...)
+		}
+		return q
+	}, nil
+}
+
+// getSortClausesFromParams builds the ORDER BY clauses, their args and the joins they require for the provided sort
+// params.  Sorts that `dialect` does not support return an error.
+func getSortClausesFromParams(ctx context.Context, model interface{}, params buffalo.ParamValues, dialect Dialect) ([]string, [][]interface{}, []Join, error) {
 	v := reflect.ValueOf(model)
 	if v.Kind() != reflect.Struct {
-		return nil, errors.New("struct expected")
+		return nil, nil, nil, errors.New("struct expected")
 	}
 	modelPtr := reflect.New(reflect.TypeOf(model)).Interface()
 
-	filterSeparator := getFilterSeparator(params)
+	// Columns are generated for the dialect, including custom columns.
+	ctx = WithDialect(ctx, dialect)
+
+	filterSeparator := getFilterSeparator(ctx, params)
 
 	columns := make([]string, 0)
 	if !util.IsBlank(params.Get("sort_columns")) {
@@ -237,60 +482,95 @@ func ForSortFromParams(ctx context.Context, model interface{}, params buffalo.Pa
 	if !util.IsBlank(params.Get("sort_directions")) {
 		directions = strings.Split(params.Get("sort_directions"), filterSeparator)
 	}
+	values := make([]string, 0)
+	if !util.IsBlank(params.Get("sort_values")) {
+		values = strings.Split(params.Get("sort_values"), filterSeparator)
+	}
 
 	// If nothing is specified, this is a no-op.
 	if len(columns) == 0 && len(directions) == 0 {
-		return func(q *pop.Query) *pop.Query {
-			return q
-		}, nil
+		return nil, nil, nil, nil
 	}
 
-	if len(columns) != len(directions) {
-		// We must have the same number of all sorting params.
-		return nil, errors.New("missing or mismatched sort parameters")
+	if len(columns) != len(directions) || (len(values) > 0 && len(columns) != len(values)) {
+		// We must have the same number of all sorting params.  Values are only needed for similarity sorts.
+		return nil, nil, nil, newParamError(ParamErrorCodeMismatchedParams, "", "", "missing or mismatched sort parameters")
+	}
+
+	if limits := LimitsFromContext(ctx); exceeds(len(columns), limits.MaxSortColumns) {
+		return nil, nil, nil, newParamError(ParamErrorCodeLimitExceeded, "sort_columns", "", "too many sort columns: more than %v", limits.MaxSortColumns)
 	}
 
 	// Check for custom sort fields, and handle appropriately.
 	columnMap := make(map[string]string, 0)
+	columnJoins := make(map[string][]Join, 0)
 	sortColumns, err := GetAllSortColumns(ctx, modelPtr)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	for _, column := range sortColumns {
 		columnMap[column.Name] = column.Statement
+		columnJoins[column.Name] = column.Joins
 	}
 
 	clauses := make([]string, len(columns))
+	clauseArgs := make([][]interface{}, len(columns))
+	joins := make([]Join, 0)
 	for i, col := range columns {
 		// Find the correct operator for this filter.
 		op, ok := sortDirections[strings.ToUpper(directions[i])]
 		if !ok {
-			return nil, errors.New(fmt.Sprintf("invalid sort direction: %v", directions[i]))
+			return nil, nil, nil, newClauseError(ParamErrorCodeInvalidType, "sort_directions", i, directions[i], "invalid sort direction: %v", directions[i])
+		} else if !dialect.Supports(directions[i]) {
+			return nil, nil, nil, newClauseError(ParamErrorCodeUnsupported, "sort_directions", i, directions[i], "invalid sort direction: %v is not supported by %v", directions[i], dialect.Name())
 		}
 
-		// If this column is sortable, build this clause.
-		if stmt, ok := columnMap[col]; ok {
-			clauses[i] = fmt.Sprintf("%s %s", stmt, op)
-		} else {
-			return nil, errors.Errorf("invalid sort field: %v", col)
+		// If this column is sortable, find its statement.
+		stmt, ok := columnMap[col]
+		if !ok && col == SearchRelevanceColumn {
+			// Relevance is sortable for searchable models, unless overridden by a custom sort.
+			if !dialect.Supports(FeatureSearch) {
+				return nil, nil, nil, newClauseError(ParamErrorCodeUnsupported, "sort_columns", i, col, "invalid sort field: %v is not supported by %v", col, dialect.Name())
+			}
+
+			var err error
+			stmt, clauseArgs[i], err = getSearchRank(ctx, model, params)
+			if err != nil {
+				return nil, nil, nil, atClause(err, "sort_columns", i)
+			}
+		} else if !ok {
+			return nil, nil, nil, newClauseError(ParamErrorCodeInvalidField, "sort_columns", i, col, "invalid sort field: %v", col)
 		}
+
+		// Similarity sorts order by the similarity to their value, most similar first.
+		if strings.ToUpper(directions[i]) == "SIM" {
+			if len(values) == 0 {
+				return nil, nil, nil, newParamError(ParamErrorCodeMismatchedParams, "", "", "missing or mismatched sort parameters")
+			}
+
+			stmt = fmt.Sprintf("similarity(CAST(%s AS TEXT), ?)", stmt)
+			clauseArgs[i] = append(clauseArgs[i], values[i])
+		}
+
+		clauses[i] = fmt.Sprintf("%s %s", stmt, op)
+		joins = append(joins, columnJoins[col]...)
 	}
 
-	return ForOrder(clauses...), nil
+	return clauses, clauseArgs, joins, nil
 }
 
-// ForPaginateFromParams paginates a query based on a list of parameters, generally c.Params()
+// ForPaginateFromParams paginates a query based on a list of parameters, generally c.Params(), with the pagination of
+// the default config, see ConfigFromContext.  Config.ForPaginateFromParams paginates with the pagination of another
+// config.
 func ForPaginateFromParams(params buffalo.ParamValues) pop.ScopeFunc {
-	return func(q *pop.Query) *pop.Query {
-		return q.PaginateFromParams(params)
-	}
+	return ConfigFromContext(nil).ForPaginateFromParams(params)
 }
 
-// getFilterSeparator gets the filterSeparator token. The parameter filter_separator can be used to separate the filter
-// columns, etc, if | is not suitable.
-func getFilterSeparator(params buffalo.ParamValues) string {
-	filterSeparator := "|"
+// getFilterSeparator gets the filterSeparator token, which is the FilterSeparator of the config of the context. The
+// parameter filter_separator can be used to separate the filter columns, etc, if it is not suitable.
+func getFilterSeparator(ctx context.Context, params buffalo.ParamValues) string {
+	filterSeparator := ConfigFromContext(ctx).FilterSeparator
 	if !util.IsBlank(params.Get("filter_separator")) {
 		filterSeparator = params.Get("filter_separator")
 	}
@@ -298,10 +578,11 @@ func getFilterSeparator(params buffalo.ParamValues) string {
 	return filterSeparator
 }
 
-// getFilterArgsSeparator gets the filterSeparator token. The parameter filter_args_separator can be used to separate
-// the filter args for operators with many args, if , is not suitable.
-func getFilterArgsSeparator(params buffalo.ParamValues) string {
-	filterArgsSeparator := ","
+// getFilterArgsSeparator gets the filterSeparator token, which is the FilterArgsSeparator of the config of the
+// context. The parameter filter_args_separator can be used to separate the filter args for operators with many args, if
+// it is not suitable.
+func getFilterArgsSeparator(ctx context.Context, params buffalo.ParamValues) string {
+	filterArgsSeparator := ConfigFromContext(ctx).FilterArgsSeparator
 	if !util.IsBlank(params.Get("filter_args_separator")) {
 		filterArgsSeparator = params.Get("filter_args_separator")
 	}
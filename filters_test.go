@@ -42,7 +42,7 @@ func (t TestModel) GetCustomSorts(ctx context.Context) scope.CustomColumns {
 func (ss *ScopesSuite) TestForFiltersFromParams() {
 	tm := TestModel{}
 	pm := &pop.Model{Value: tm}
-	baseQuery, _ := ss.DB.Q().ToSQL(pm)
+	baseQuery, _ := ss.dryRunDB("postgres").Q().ToSQL(pm)
 
 	testCases := []struct {
 		Name          string
@@ -267,6 +267,51 @@ func (ss *ScopesSuite) TestForFiltersFromParams() {
 			ExpectedQuery: fmt.Sprintf("%s WHERE (test_models.id ilike $1)", baseQuery),
 			ExpectedArgs:  []string{"%test%"},
 		},
+		{
+			Name: "Similarity Operator",
+			Params: map[string][]string{
+				"filter_columns": {"id"},
+				"filter_types":   {"sim"},
+				"filter_values":  {"test"},
+			},
+			ExpectErr:     false,
+			ExpectedQuery: fmt.Sprintf("%s WHERE ((CAST(test_models.id AS TEXT) %% $1 AND similarity(CAST(test_models.id AS TEXT), $2) >= 0.3))", baseQuery),
+			ExpectedArgs:  []string{"test", "test"},
+		},
+		{
+			Name: "Similarity Operator With Threshold",
+			Params: map[string][]string{
+				"filter_columns":              {"id"},
+				"filter_types":                {"sim"},
+				"filter_values":               {"test"},
+				"filter_similarity_threshold": {"0.5"},
+			},
+			ExpectErr:     false,
+			ExpectedQuery: fmt.Sprintf("%s WHERE ((CAST(test_models.id AS TEXT) %% $1 AND similarity(CAST(test_models.id AS TEXT), $2) >= 0.5))", baseQuery),
+			ExpectedArgs:  []string{"test", "test"},
+		},
+		{
+			Name: "Similarity Operator Below Operator Threshold",
+			Params: map[string][]string{
+				"filter_columns":              {"id"},
+				"filter_types":                {"sim"},
+				"filter_values":               {"test"},
+				"filter_similarity_threshold": {"0.1"},
+			},
+			ExpectErr:     false,
+			ExpectedQuery: fmt.Sprintf("%s WHERE (similarity(CAST(test_models.id AS TEXT), $1) >= 0.1)", baseQuery),
+			ExpectedArgs:  []string{"test"},
+		},
+		{
+			Name: "Similarity Operator Invalid Threshold",
+			Params: map[string][]string{
+				"filter_columns":              {"id"},
+				"filter_types":                {"sim"},
+				"filter_values":               {"test"},
+				"filter_similarity_threshold": {"2"},
+			},
+			ExpectErr: true,
+		},
 		{
 			Name: "In Operator",
 			Params: map[string][]string{
@@ -508,7 +553,7 @@ func (ss *ScopesSuite) TestForFiltersFromParams() {
 			}
 
 			assert.NoError(t, err)
-			query, args := ss.DB.Q().Scope(s).ToSQL(pm)
+			query, args := ss.dryRunDB("postgres").Q().Scope(s).ToSQL(pm)
 			assert.Equal(t, testCase.ExpectedQuery, query)
 			assert.Equal(t, len(testCase.ExpectedArgs), len(args))
 
@@ -522,7 +567,7 @@ func (ss *ScopesSuite) TestForFiltersFromParams() {
 func (ss *ScopesSuite) TestForOrderFromParams() {
 	tm := TestModel{}
 	pm := &pop.Model{Value: tm}
-	baseQuery, _ := ss.DB.Q().ToSQL(pm)
+	baseQuery, _ := ss.dryRunDB("postgres").Q().ToSQL(pm)
 
 	testCases := []struct {
 		Name          string
@@ -599,6 +644,24 @@ func (ss *ScopesSuite) TestForOrderFromParams() {
 			ExpectErr:     false,
 			ExpectedQuery: fmt.Sprintf("%s ORDER BY test_models.id ASC, test_models.db_null_id DESC", baseQuery),
 		},
+		{
+			Name: "SIM Direction",
+			Params: map[string][]string{
+				"sort_columns":    {"id"},
+				"sort_directions": {"sim"},
+				"sort_values":     {"test"},
+			},
+			ExpectErr:     false,
+			ExpectedQuery: fmt.Sprintf("%s ORDER BY similarity(CAST(test_models.id AS TEXT), $1) DESC", baseQuery),
+		},
+		{
+			Name: "SIM Direction Missing Value",
+			Params: map[string][]string{
+				"sort_columns":    {"id"},
+				"sort_directions": {"sim"},
+			},
+			ExpectErr: true,
+		},
 		{
 			Name: "Multiple Sorts Mismatched Fields",
 			Params: map[string][]string{
@@ -627,7 +690,7 @@ func (ss *ScopesSuite) TestForOrderFromParams() {
 
 			assert.NoError(t, err)
 
-			query, _ := ss.DB.Q().Scope(s).ToSQL(pm)
+			query, _ := ss.dryRunDB("postgres").Q().Scope(s).ToSQL(pm)
 			assert.Equal(t, testCase.ExpectedQuery, query)
 		})
 	}
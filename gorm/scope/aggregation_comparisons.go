@@ -0,0 +1,363 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/alphaflow/scope/util"
+)
+
+type AggregationComparisonType string
+
+const (
+	// AggregationComparisonTypePreviousPeriod compares to the period of equal length immediately before the current period.
+	AggregationComparisonTypePreviousPeriod AggregationComparisonType = "PREVIOUS_PERIOD"
+	// AggregationComparisonTypePreviousYear compares to the same period one year before the current period.
+	AggregationComparisonTypePreviousYear AggregationComparisonType = "PREVIOUS_YEAR"
+	// AggregationComparisonTypeCustom compares to the current period shifted back by a custom offset.
+	AggregationComparisonTypeCustom AggregationComparisonType = "CUSTOM"
+)
+
+// AggregationComparison describes a period-over-period comparison.  The current period is the half open range
+// [Start, End) of the date column ColumnName, and the previous period is derived from the current period by Type.
+//
+// OffsetYears, OffsetMonths and OffsetDays are the amount the current period is shifted back for
+// AggregationComparisonTypeCustom.
+type AggregationComparison struct {
+	ColumnName   string
+	Start        time.Time
+	End          time.Time
+	Type         AggregationComparisonType
+	OffsetYears  int
+	OffsetMonths int
+	OffsetDays   int
+}
+
+// ComparedAggregation is the result of an aggregation for both periods of an AggregationComparison.  Change and
+// PercentChange are only set for numeric aggregations, and PercentChange is nil when the previous value is 0.
+type ComparedAggregation struct {
+	Current       interface{} `json:"current"`
+	Previous      interface{} `json:"previous"`
+	Change        interface{} `json:"change"`
+	PercentChange interface{} `json:"percent_change"`
+}
+
+// comparisonOffsetRegex matches a custom comparison offset, ex. "1 year", "3 months" or "14 days".
+var comparisonOffsetRegex = regexp.MustCompile(`^(\d+)\s*(day|days|week|weeks|month|months|year|years)$`)
+
+// PreviousPeriod returns the start and end of the period the current period is compared to.
+func (c AggregationComparison) PreviousPeriod() (time.Time, time.Time, error) {
+	if !c.Start.Before(c.End) {
+		return time.Time{}, time.Time{}, newParamError(ParamErrorCodeInvalidValue, "aggregation_comparison_end", c.End.Format(time.RFC3339), "invalid aggregation comparison period")
+	}
+
+	switch AggregationComparisonType(strings.ToUpper(string(c.Type))) {
+	case AggregationComparisonTypePreviousPeriod:
+		return c.Start.Add(-c.End.Sub(c.Start)), c.Start, nil
+	case AggregationComparisonTypePreviousYear:
+		return c.Start.AddDate(-1, 0, 0), c.End.AddDate(-1, 0, 0), nil
+	case AggregationComparisonTypeCustom:
+		if c.OffsetYears == 0 && c.OffsetMonths == 0 && c.OffsetDays == 0 {
+			return time.Time{}, time.Time{}, newParamError(ParamErrorCodeMismatchedParams, "aggregation_comparison_offset", "", "missing aggregation comparison offset")
+		}
+
+		return c.Start.AddDate(-c.OffsetYears, -c.OffsetMonths, -c.OffsetDays), c.End.AddDate(-c.OffsetYears, -c.OffsetMonths, -c.OffsetDays), nil
+	}
+
+	return time.Time{}, time.Time{}, newParamError(ParamErrorCodeInvalidType, "aggregation_comparison_type", string(c.Type), "invalid aggregation comparison type: %v", c.Type)
+}
+
+// getAggregationComparisonFromParams builds an AggregationComparison from the `aggregation_comparison_*` params.
+func getAggregationComparisonFromParams(ctx context.Context, params buffalo.ParamValues) (AggregationComparison, error) {
+	comparison := AggregationComparison{
+		ColumnName: params.Get("aggregation_comparison_column"),
+		Type:       AggregationComparisonType(strings.ToUpper(params.Get("aggregation_comparison_type"))),
+	}
+
+	if util.IsBlank(string(comparison.Type)) {
+		comparison.Type = AggregationComparisonTypePreviousPeriod
+	}
+
+	var err error
+	comparison.Start, err = parseComparisonTime("aggregation_comparison_start", params.Get("aggregation_comparison_start"), ConfigFromContext(ctx).TimeZone)
+	if err != nil {
+		return comparison, err
+	}
+
+	comparison.End, err = parseComparisonTime("aggregation_comparison_end", params.Get("aggregation_comparison_end"), ConfigFromContext(ctx).TimeZone)
+	if err != nil {
+		return comparison, err
+	}
+
+	if comparison.Type == AggregationComparisonTypeCustom {
+		matches := comparisonOffsetRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(params.Get("aggregation_comparison_offset"))))
+		if matches == nil {
+			return comparison, newParamError(ParamErrorCodeInvalidValue, "aggregation_comparison_offset", params.Get("aggregation_comparison_offset"), "invalid aggregation comparison offset: %v", params.Get("aggregation_comparison_offset"))
+		}
+
+		amount, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return comparison, newParamError(ParamErrorCodeInvalidValue, "aggregation_comparison_offset", params.Get("aggregation_comparison_offset"), "invalid aggregation comparison offset: %v", params.Get("aggregation_comparison_offset"))
+		}
+
+		switch strings.TrimSuffix(matches[2], "s") {
+		case "day":
+			comparison.OffsetDays = amount
+		case "week":
+			comparison.OffsetDays = amount * 7
+		case "month":
+			comparison.OffsetMonths = amount
+		case "year":
+			comparison.OffsetYears = amount
+		}
+	}
+
+	return comparison, nil
+}
+
+// parseComparisonTime parses either an RFC 3339 timestamp, or a date which is assumed to be in the time zone
+// `location`, or UTC if it is nil.
+func parseComparisonTime(param, value string, location *time.Location) (time.Time, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, newParamError(ParamErrorCodeInvalidValue, param, value, "invalid aggregation comparison date: %v", value)
+}
+
+// GetComparisonAggregations returns the aggregated value for column `columnName` of modelsPtr for both periods of
+// `comparison`, restricting by the scope collection `scopes`.
+//
+// `columnName` and the comparison's ColumnName are either a CustomColumn returned by the CustomFilterable interface, or
+// a field specified by the json tag.  This is the same as the acceptable values for `filter_columns` in
+// ForFiltersFromParams.
+func GetComparisonAggregations(ctx context.Context, tx *gorm.DB, modelsPtr interface{}, columnNames []string, scopes *Collection, aggregations Aggregations, comparison AggregationComparison) (interface{}, error) {
+	ctx = withCollectionConfig(ctx, scopes)
+
+	v := reflect.ValueOf(modelsPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return nil, errors.New("pointer to slice expected")
+	}
+
+	models := v.Elem()
+	modelPtr := reflect.New(models.Type().Elem()).Interface()
+
+	// Columns are generated for the dialect of the connection.
+	ctx = WithDialect(ctx, getDialect(tx))
+	filterColumns, err := GetAllFilterColumns(ctx, modelPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	var dateColumn *CustomColumn
+	for i, filterColumn := range filterColumns {
+		if comparison.ColumnName == filterColumn.Name {
+			dateColumn = &filterColumns[i]
+			break
+		}
+	}
+
+	if dateColumn == nil {
+		return nil, newParamError(ParamErrorCodeInvalidField, "aggregation_comparison_column", comparison.ColumnName, "invalid filter field: %v", comparison.ColumnName)
+	}
+
+	customColumns := CustomColumns{}
+	for i, columnName := range columnNames {
+		var column *CustomColumn
+		for j, filterColumn := range filterColumns {
+			if columnName == filterColumn.Name {
+				column = &filterColumns[j]
+				break
+			}
+		}
+
+		if column == nil {
+			return nil, newClauseError(ParamErrorCodeInvalidField, "aggregation_column", i, columnName, "invalid filter field: %v", columnName)
+		}
+
+		customColumns = append(customColumns, *column)
+	}
+
+	tableName := tableName(modelPtr, namingStrategyFromContext(ctx))
+	return getCustomComparisonAggregations(ctx, tx, tableName, customColumns, *dateColumn, scopes, aggregations, comparison)
+}
+
+// getCustomComparisonAggregations returns the aggregated value for the provided `customColumns` from the table
+// `tableName` for both periods of `comparison`, after scoping said table by `scopes`.
+//
+// In order to do this in a single query, each aggregation is applied twice, conditionally on the `dateColumn` being
+// within the current or the previous period.  The change between the periods is then computed from the results.
+func getCustomComparisonAggregations(ctx context.Context, tx *gorm.DB, tableName string, customColumns CustomColumns, dateColumn CustomColumn, scopes *Collection, aggregations Aggregations, comparison AggregationComparison) (interface{}, error) {
+	clauses := ""
+
+	previousStart, previousEnd, err := comparison.PreviousPeriod()
+	if err != nil {
+		return nil, err
+	}
+
+	periods := [][]interface{}{{comparison.Start, comparison.End}, {previousStart, previousEnd}}
+	periodStatement := fmt.Sprintf("%v >= ? AND %v < ?", dateColumn.Statement, dateColumn.Statement)
+
+	comparisonScopes := NewCollection(tx)
+
+	// Only the rows within either period are aggregated.
+	comparisonScopes.Push(func(q *gorm.DB) *gorm.DB {
+		return q.Where(fmt.Sprintf("((%v) OR (%v))", periodStatement, periodStatement), comparison.Start, comparison.End, previousStart, previousEnd)
+	})
+
+	queryStructFields := make([]reflect.StructField, 0, len(aggregations)*2)
+	outputStructFields := make([]reflect.StructField, len(aggregations))
+	queryStubs := make([]string, 0, len(aggregations)*2)
+	aggregationArgs := make([]interface{}, 0)
+	jsonKeySet := make(map[string]bool)
+	for i, aggregation := range aggregations {
+		if aggregation.Modifier != nil {
+			return nil, newClauseError(ParamErrorCodeUnsupported, "aggregation_modifier", i, aggregation.Modifier.Name, "aggregation modifiers require an aggregation grouper")
+		}
+
+		jsonKey := fmt.Sprintf("%v_%v", strings.ToLower(aggregation.Name), customColumns[i].Name)
+		if _, ok := jsonKeySet[jsonKey]; ok {
+			return nil, newClauseError(ParamErrorCodeDuplicateParam, "aggregation_column", i, customColumns[i].Name, "duplicate aggregation parameter")
+		}
+
+		jsonKeySet[jsonKey] = true
+
+		// If the aggregation doesn't have a defined output type, assume the output is the same type as the field.
+		resultType := aggregation.ResultType
+		if resultType == nil {
+			resultType = customColumns[i].ResultType
+		}
+
+		// Both periods are scanned into pointers, since a period without any rows is null for most aggregations.
+		for j, period := range []string{"Current", "Previous"} {
+			queryStructFields = append(queryStructFields, reflect.StructField{
+				Name: fmt.Sprintf("%v%v", period, i),
+				Type: reflect.PtrTo(resultType),
+				Tag:  reflect.StructTag(fmt.Sprintf(`db:"%v%v"`, strings.ToLower(period), i)),
+			})
+
+			// Conditionally aggregate only the values belonging to this period.
+			periodColumn := customColumns[i]
+			periodColumn.Statement = fmt.Sprintf("(CASE WHEN %v THEN %v END)", periodStatement, customColumns[i].Statement)
+			aggregationStatement, statementArgs := aggregation.statementFor(periodColumn, periods[j]...)
+
+			queryStubs = append(queryStubs, fmt.Sprintf("%v AS %v%v", aggregationStatement, strings.ToLower(period), i))
+			aggregationArgs = append(aggregationArgs, statementArgs...)
+		}
+
+		outputStructFields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Result%v", i),
+			Type: reflect.TypeOf(ComparedAggregation{}),
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:"%v"`, jsonKey)),
+		}
+
+		// We never aggregate null values.
+		comparisonScopes.Push(ForNotNull(customColumns[i].Statement), ForJoins(customColumns[i].Joins...))
+	}
+
+	comparisonScopes.Push(ForJoins(dateColumn.Joins...))
+
+	typedStructWithDBTag := reflect.New(reflect.StructOf(queryStructFields))
+
+	if scopes != nil && len(scopes.scopes) > 0 {
+		comparisonScopes.Push(scopes.scopes...)
+	}
+
+	q := tx.Session(&gorm.Session{DryRun: true}).Model(__stub__{})
+	q.Statement.SQL.Reset()
+	scopeQueryFunc := comparisonScopes.Flatten()(q).Find(q.Statement.Model)
+	scopeQuerySQL := scopeQueryFunc.Statement.SQL.String()
+	scopeQuerySQL = strings.Replace(scopeQuerySQL, "SELECT *", "SELECT ", 1)
+	scopeQueryArgs := scopeQueryFunc.Statement.Vars
+	stubRegex := regexp.MustCompile(stubRegex)
+	clauses = stubRegex.ReplaceAllString(scopeQuerySQL, "")
+
+	// Strip all order by columns out of the query, since they don't matter.
+	orderRegex := regexp.MustCompile(`ORDER\s+BY\s+\w+(\s+ASC|\s+DESC)?([\s,]*\w+(\s+ASC|\s+DESC)?)*`)
+	clauses = orderRegex.ReplaceAllString(clauses, "")
+
+	generatedStatement := fmt.Sprintf("SELECT %v FROM %v %v", numberPlaceholders(strings.Join(queryStubs, ", "), len(scopeQueryArgs), getDialect(tx)), fromTable(getDialect(tx), tableName), clauses)
+	queryArgs := bindPlaceholderArgs(getDialect(tx), scopeQueryArgs, aggregationArgs)
+	if err := checkQueryCost(ctx, tx, generatedStatement, queryArgs...); err != nil {
+		return nil, err
+	}
+
+	err = SimilarityError(tx.Raw(generatedStatement, queryArgs...).First(typedStructWithDBTag.Interface()).Error)
+	if err != nil {
+		return nil, err
+	}
+
+	output := reflect.New(reflect.StructOf(outputStructFields))
+	for i := range aggregations {
+		current := typedStructWithDBTag.Elem().Field(i * 2)
+		previous := typedStructWithDBTag.Elem().Field(i*2 + 1)
+
+		compared := ComparedAggregation{}
+		if !current.IsNil() {
+			compared.Current = current.Elem().Interface()
+		}
+
+		if !previous.IsNil() {
+			compared.Previous = previous.Elem().Interface()
+		}
+
+		compared.Change, compared.PercentChange = compareAggregationValues(compared.Current, compared.Previous)
+		output.Elem().Field(i).Set(reflect.ValueOf(compared))
+	}
+
+	return output.Interface(), nil
+}
+
+// compareAggregationValues returns the change from `previous` to `current`, and that change as a percentage of
+// `previous`.  The change is only defined for numeric values.
+func compareAggregationValues(current, previous interface{}) (interface{}, interface{}) {
+	if current == nil || previous == nil {
+		return nil, nil
+	}
+
+	currentValue := reflect.ValueOf(current)
+	previousValue := reflect.ValueOf(previous)
+	if currentValue.Type() != previousValue.Type() {
+		return nil, nil
+	}
+
+	var change interface{}
+	var currentFloat, previousFloat float64
+	switch currentValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		change = reflect.ValueOf(currentValue.Int() - previousValue.Int()).Convert(currentValue.Type()).Interface()
+		currentFloat, previousFloat = float64(currentValue.Int()), float64(previousValue.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		change = int64(currentValue.Uint()) - int64(previousValue.Uint())
+		currentFloat, previousFloat = float64(currentValue.Uint()), float64(previousValue.Uint())
+	case reflect.Float32, reflect.Float64:
+		change = reflect.ValueOf(currentValue.Float() - previousValue.Float()).Convert(currentValue.Type()).Interface()
+		currentFloat, previousFloat = currentValue.Float(), previousValue.Float()
+	default:
+		return nil, nil
+	}
+
+	if previousFloat == 0 {
+		return change, nil
+	}
+
+	return change, (currentFloat - previousFloat) * 100 / math.Abs(previousFloat)
+}
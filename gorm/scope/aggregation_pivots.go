@@ -0,0 +1,259 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/alphaflow/scope/util"
+)
+
+// AggregationPivotColumnsMax is the maximum number of distinct values the pivot column may have.  Each value becomes a
+// column of the pivot, so this bounds the width of both the generated query and the returned matrix.  It is the
+// default AggregationPivotColumnsMax of NewConfig.
+var AggregationPivotColumnsMax = 100
+
+// PivotAggregation is a matrix of aggregated values.  Values[i][j] is the aggregation of all rows where the grouper
+// column equals RowHeaders[i] and the pivot column equals ColumnHeaders[j].  Cells without any rows hold the
+// aggregation of no rows, which is 0 for COUNT and nil for SUM, AVG, MAX and MIN.
+type PivotAggregation struct {
+	Name          string          `json:"name"`
+	RowHeaders    []interface{}   `json:"row_headers"`
+	ColumnHeaders []interface{}   `json:"column_headers"`
+	Values        [][]interface{} `json:"values"`
+}
+
+// pivotHeadersQueryResult is a struct with an interface column.  The type of interface is swapped out using
+// reflection in getCustomPivotAggregations in order to be able to scan DB values into any type as needed.
+type pivotHeadersQueryResult struct {
+	Result interface{} `db:"result" gorm:"column:result"`
+}
+
+// GetPivotAggregationsFromParams aggregates a modelsPtr into a matrix based on params, restricting by the scope
+// collection scopes.  Rows are grouped by `aggregation_grouper_column`, and columns by `aggregation_pivot_column`.
+func GetPivotAggregationsFromParams(ctx context.Context, tx *gorm.DB, modelsPtr interface{}, params buffalo.ParamValues, scopes *Collection) (*PivotAggregation, error) {
+	ctx, params = withParamsConfig(ctx, params, scopes)
+
+	columnName := params.Get("aggregation_column")
+	aggregationType := params.Get("aggregation_type")
+	rowGrouperName := params.Get("aggregation_grouper_column")
+	columnGrouperName := params.Get("aggregation_pivot_column")
+
+	if util.IsBlank(columnName) || util.IsBlank(aggregationType) || util.IsBlank(rowGrouperName) || util.IsBlank(columnGrouperName) {
+		// A pivot is always a single aggregation, grouped by exactly 2 columns.
+		return nil, newParamError(ParamErrorCodeMismatchedParams, "", "", "missing or mismatched aggregation parameters")
+	}
+
+	if strings.Contains(columnName, getFilterSeparator(ctx, params)) || strings.Contains(aggregationType, getFilterSeparator(ctx, params)) {
+		return nil, newParamError(ParamErrorCodeUnsupported, "aggregation_column", columnName, "pivot aggregations support a single aggregation")
+	}
+
+	aggregations, err := getAggregationsForTypes(ctx, modelsPtr, []string{aggregationType})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetPivotAggregations(ctx, tx, modelsPtr, columnName, rowGrouperName, columnGrouperName, scopes, aggregations[0])
+}
+
+// GetPivotAggregations returns the aggregated value for column `columnName` of modelsPtr, grouped into rows by
+// `rowGrouperName` and into columns by `columnGrouperName`, restricting by the scope collection `scopes`.
+//
+// `columnName`, `rowGrouperName` and `columnGrouperName` are either a CustomColumn returned by the CustomFilterable
+// interface, or a field specified by the json tag.  This is the same as the acceptable values for `filter_columns` in
+// ForFiltersFromParams.
+func GetPivotAggregations(ctx context.Context, tx *gorm.DB, modelsPtr interface{}, columnName, rowGrouperName, columnGrouperName string, scopes *Collection, aggregation Aggregation) (*PivotAggregation, error) {
+	ctx = withCollectionConfig(ctx, scopes)
+
+	v := reflect.ValueOf(modelsPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return nil, errors.New("pointer to slice expected")
+	}
+
+	models := v.Elem()
+	modelPtr := reflect.New(models.Type().Elem()).Interface()
+
+	// Columns are generated for the dialect of the connection.
+	ctx = WithDialect(ctx, getDialect(tx))
+	filterColumns, err := GetAllFilterColumns(ctx, modelPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	var column, rowGrouper, columnGrouper *CustomColumn
+	for i, filterColumn := range filterColumns {
+		if columnName == filterColumn.Name {
+			column = &filterColumns[i]
+		}
+
+		if rowGrouperName == filterColumn.Name {
+			rowGrouper = &filterColumns[i]
+		}
+
+		if columnGrouperName == filterColumn.Name {
+			columnGrouper = &filterColumns[i]
+		}
+	}
+
+	if column == nil {
+		return nil, newParamError(ParamErrorCodeInvalidField, "aggregation_column", columnName, "invalid filter field: %v", columnName)
+	}
+
+	if rowGrouper == nil {
+		return nil, newParamError(ParamErrorCodeInvalidField, "aggregation_grouper_column", rowGrouperName, "invalid filter field: %v", rowGrouperName)
+	}
+
+	if columnGrouper == nil {
+		return nil, newParamError(ParamErrorCodeInvalidField, "aggregation_pivot_column", columnGrouperName, "invalid filter field: %v", columnGrouperName)
+	}
+
+	tableName := tableName(modelPtr, namingStrategyFromContext(ctx))
+	return getCustomPivotAggregations(ctx, tx, tableName, *column, *rowGrouper, *columnGrouper, scopes, aggregation)
+}
+
+// getCustomPivotAggregations returns the aggregated value for the provided `customColumn` from the table `tableName`,
+// after scoping said table by `scopes`, as a matrix of `rowGrouper` by `columnGrouper`.
+//
+// In order to do this, we first fetch the distinct values of columnGrouper, which become the column headers.  We then
+// build a scoped GROUP BY query on rowGrouper, with one conditional aggregation per column header.
+func getCustomPivotAggregations(ctx context.Context, tx *gorm.DB, tableName string, customColumn, rowGrouper, columnGrouper CustomColumn, scopes *Collection, aggregation Aggregation) (*PivotAggregation, error) {
+	if aggregation.Modifier != nil {
+		return nil, newParamError(ParamErrorCodeUnsupported, "aggregation_modifier", aggregation.Modifier.Name, "aggregation modifiers are not supported by pivot aggregations")
+	}
+
+	clauses := ""
+
+	pivotScopes := NewCollection(tx)
+
+	// We never aggregate null values, and null values of the pivot column cannot be matched to a column header.
+	pivotScopes.Push(ForNotNull(customColumn.Statement), ForNotNull(columnGrouper.Statement))
+	pivotScopes.Push(ForJoins(customColumn.Joins...), ForJoins(rowGrouper.Joins...), ForJoins(columnGrouper.Joins...))
+
+	if scopes != nil && len(scopes.scopes) > 0 {
+		pivotScopes.Push(scopes.scopes...)
+	}
+
+	q := tx.Session(&gorm.Session{DryRun: true}).Model(__stub__{})
+	q.Statement.SQL.Reset()
+	scopeQueryFunc := pivotScopes.Flatten()(q).Find(q.Statement.Model)
+	scopeQuerySQL := scopeQueryFunc.Statement.SQL.String()
+	scopeQuerySQL = strings.Replace(scopeQuerySQL, "SELECT *", "SELECT ", 1)
+	scopeQueryArgs := scopeQueryFunc.Statement.Vars
+	stubRegex := regexp.MustCompile(stubRegex)
+	clauses = stubRegex.ReplaceAllString(scopeQuerySQL, "")
+
+	// Strip all order by columns out of the query, since they don't matter and will break our GROUP BY.
+	orderRegex := regexp.MustCompile(`ORDER\s+BY\s+\w+(\s+ASC|\s+DESC)?([\s,]*\w+(\s+ASC|\s+DESC)?)*`)
+	clauses = orderRegex.ReplaceAllString(clauses, "")
+
+	// We need to build a struct of type "ResultType", so that we can correctly marshall the column headers from the DB.
+	templateHeaderStructField := reflect.ValueOf(pivotHeadersQueryResult{}).Type().Field(0)
+	templateHeaderStructField.Type = columnGrouper.ResultType
+	typedHeaderStructArrayPtrWithDBTag := reflect.New(reflect.SliceOf(reflect.StructOf([]reflect.StructField{templateHeaderStructField})))
+
+	// Fetch one more header than we allow, so that we can tell if the pivot is too wide.
+	pivotColumnsMax := ConfigFromContext(ctx).AggregationPivotColumnsMax
+	headersStatement := fmt.Sprintf("SELECT %v AS result FROM %v %v GROUP BY %v ORDER BY %v LIMIT %v", columnGrouper.Statement, fromTable(getDialect(tx), tableName), clauses, columnGrouper.Statement, columnGrouper.Statement, pivotColumnsMax+1)
+	if err := checkQueryCost(ctx, tx, headersStatement, scopeQueryArgs...); err != nil {
+		return nil, err
+	}
+
+	err := SimilarityError(tx.Raw(headersStatement, scopeQueryArgs...).Find(typedHeaderStructArrayPtrWithDBTag.Interface()).Error)
+	if err != nil {
+		return nil, err
+	}
+
+	headerStructs := reflect.Indirect(typedHeaderStructArrayPtrWithDBTag)
+	if headerStructs.Len() > pivotColumnsMax {
+		return nil, newParamError(ParamErrorCodeLimitExceeded, "aggregation_pivot_column", columnGrouper.Name, "too many pivot columns: %v has more than %v values", columnGrouper.Name, pivotColumnsMax)
+	}
+
+	output := &PivotAggregation{
+		Name:          fmt.Sprintf("%v_%v", strings.ToLower(aggregation.Name), customColumn.Name),
+		RowHeaders:    make([]interface{}, 0),
+		ColumnHeaders: make([]interface{}, headerStructs.Len()),
+		Values:        make([][]interface{}, 0),
+	}
+
+	if headerStructs.Len() == 0 {
+		return output, nil
+	}
+
+	// If the aggregation doesn't have a defined output type, assume the output is the same type as the field.
+	resultType := aggregation.ResultType
+	if resultType == nil {
+		resultType = customColumn.ResultType
+	}
+
+	templateGrouperStructField, ok := reflect.ValueOf(groupedAggregationsQueryResult{}).Type().FieldByName("Grouper")
+	if !ok {
+		return nil, errors.New("unable to build pivot aggregation query result")
+	}
+
+	templateGrouperStructField.Type = rowGrouper.ResultType
+
+	structFields := make([]reflect.StructField, headerStructs.Len()+1)
+	structFields[0] = templateGrouperStructField
+
+	queryStubs := make([]string, headerStructs.Len())
+	aggregationArgs := make([]interface{}, 0)
+	for i := 0; i < headerStructs.Len(); i++ {
+		header := headerStructs.Index(i).Field(0).Interface()
+		output.ColumnHeaders[i] = header
+
+		templateStructField, ok := reflect.ValueOf(aggregationsQueryResult{}).Type().FieldByName("Result")
+		if !ok {
+			return nil, errors.New("unable to build pivot aggregation query result")
+		}
+
+		// Cells are scanned into pointers, since a cell without any rows is null for most aggregations.
+		templateStructField.Name = fmt.Sprintf("Result%v", i)
+		templateStructField.Type = reflect.PtrTo(resultType)
+		templateStructField.Tag = reflect.StructTag(fmt.Sprintf(`db:"result%v"`, i))
+		structFields[i+1] = templateStructField
+
+		// Conditionally aggregate only the values belonging to this column header.
+		conditionalColumn := customColumn
+		conditionalColumn.Statement = fmt.Sprintf("(CASE WHEN %v = ? THEN %v END)", columnGrouper.Statement, customColumn.Statement)
+		aggregationStatement, statementArgs := aggregation.statementFor(conditionalColumn, header)
+
+		queryStubs[i] = fmt.Sprintf("%v AS result%v", aggregationStatement, i)
+		aggregationArgs = append(aggregationArgs, statementArgs...)
+	}
+
+	typedStructArrayPtrWithDBTag := reflect.New(reflect.SliceOf(reflect.StructOf(structFields)))
+
+	generatedStatement := fmt.Sprintf("SELECT %v AS grouper, %v FROM %v %v GROUP BY %v ORDER BY %v", rowGrouper.Statement, numberPlaceholders(strings.Join(queryStubs, ", "), len(scopeQueryArgs), getDialect(tx)), fromTable(getDialect(tx), tableName), clauses, rowGrouper.Statement, rowGrouper.Statement)
+	queryArgs := bindPlaceholderArgs(getDialect(tx), scopeQueryArgs, aggregationArgs)
+	if err := checkQueryCost(ctx, tx, generatedStatement, queryArgs...); err != nil {
+		return nil, err
+	}
+
+	err = SimilarityError(tx.Raw(generatedStatement, queryArgs...).Find(typedStructArrayPtrWithDBTag.Interface()).Error)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := reflect.Indirect(typedStructArrayPtrWithDBTag)
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		output.RowHeaders = append(output.RowHeaders, row.Field(0).Interface())
+
+		values := make([]interface{}, len(output.ColumnHeaders))
+		for j := range output.ColumnHeaders {
+			if cell := row.Field(j + 1); !cell.IsNil() {
+				values[j] = cell.Elem().Interface()
+			}
+		}
+
+		output.Values = append(output.Values, values)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,189 @@
+package scope_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+
+	"github.com/alphaflow/scope/gorm/scope"
+	"github.com/alphaflow/scope/util"
+)
+
+func (ss *ScopesSuite) TestGetPivotAggregationsFromParams_Count() {
+	nuid := util.UuidMust()
+	for _, number := range []float64{1, 2, 2} {
+		testObject := &TestObject{ID: uuid.Must(uuid.NewV4()), Nuid: nuid, Number: number}
+		err := ss.DB.Create(testObject).Error
+		ss.NoError(err)
+	}
+
+	testObject := &TestObject{ID: uuid.Must(uuid.NewV4()), Number: 1}
+	err := ss.DB.Create(testObject).Error
+	ss.NoError(err)
+
+	params := map[string][]string{
+		"aggregation_column":         {"id"},
+		"aggregation_type":           {"count"},
+		"aggregation_grouper_column": {"null_id"},
+		"aggregation_pivot_column":   {"num"},
+	}
+
+	pivot, err := scope.GetPivotAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Equal(ss.nullRowsOrdered(&scope.PivotAggregation{
+		Name:          "count_id",
+		RowHeaders:    []interface{}{nuid, nulls.UUID{}},
+		ColumnHeaders: []interface{}{float64(1), float64(2)},
+		Values:        [][]interface{}{{1, 2}, {1, 0}},
+	}), pivot)
+
+	jsn, err := json.Marshal(ss.nullRowsOrdered(pivot))
+	ss.NoError(err)
+	ss.Equal(fmt.Sprintf(`{"name":"count_id","row_headers":["%v",null],"column_headers":[1,2],"values":[[1,2],[1,0]]}`, nuid.UUID), string(jsn))
+}
+
+// nullRowsOrdered reverses the rows of a pivot with a null row unless the database is postgres, which sorts nulls
+// last, so that expectations can list the null row last regardless of the dialect.  Reversing twice restores the order.
+func (ss *ScopesSuite) nullRowsOrdered(pivot *scope.PivotAggregation) *scope.PivotAggregation {
+	if ss.dialect() == scope.PostgresDialect {
+		return pivot
+	}
+
+	reversed := &scope.PivotAggregation{Name: pivot.Name, ColumnHeaders: pivot.ColumnHeaders}
+	for i := len(pivot.RowHeaders) - 1; i >= 0; i-- {
+		reversed.RowHeaders = append(reversed.RowHeaders, pivot.RowHeaders[i])
+		reversed.Values = append(reversed.Values, pivot.Values[i])
+	}
+
+	return reversed
+}
+
+func (ss *ScopesSuite) TestGetPivotAggregations_Sum() {
+	nuid := util.UuidMust()
+	for _, number := range []float64{1, 2, 2} {
+		testObject := &TestObject{ID: uuid.Must(uuid.NewV4()), Nuid: nuid, Number: number}
+		err := ss.DB.Create(testObject).Error
+		ss.NoError(err)
+	}
+
+	testObject := &TestObject{ID: uuid.Must(uuid.NewV4()), Number: 3}
+	err := ss.DB.Create(testObject).Error
+	ss.NoError(err)
+
+	aggregation := scope.StandardAggregations[scope.StandardAggregationsTypeSum]
+	pivot, err := scope.GetPivotAggregations(context.Background(), ss.DB, &[]TestObject{}, "num", "null_id", "num", nil, aggregation)
+	ss.NoError(err)
+	ss.Equal(ss.nullRowsOrdered(&scope.PivotAggregation{
+		Name:          "sum_num",
+		RowHeaders:    []interface{}{nuid, nulls.UUID{}},
+		ColumnHeaders: []interface{}{float64(1), float64(2), float64(3)},
+		Values:        [][]interface{}{{float64(1), float64(4), nil}, {nil, nil, float64(3)}},
+	}), pivot)
+}
+
+func (ss *ScopesSuite) TestGetPivotAggregations_scoped() {
+	nuid := util.UuidMust()
+	testObject := &TestObject{ID: uuid.Must(uuid.NewV4()), Nuid: nuid, Number: 1}
+	err := ss.DB.Create(testObject).Error
+	ss.NoError(err)
+
+	testObject2 := &TestObject{ID: uuid.Must(uuid.NewV4()), Nuid: nuid, Number: 2}
+	err = ss.DB.Create(testObject2).Error
+	ss.NoError(err)
+
+	sc := scope.NewCollection(ss.DB)
+	sc.Push(scope.ForID(testObject.ID.String()))
+
+	aggregation := scope.StandardAggregations[scope.StandardAggregationsTypeCount]
+	pivot, err := scope.GetPivotAggregations(context.Background(), ss.DB, &[]TestObject{}, "id", "null_id", "num", sc, aggregation)
+	ss.NoError(err)
+	ss.Equal(&scope.PivotAggregation{
+		Name:          "count_id",
+		RowHeaders:    []interface{}{nuid},
+		ColumnHeaders: []interface{}{float64(1)},
+		Values:        [][]interface{}{{1}},
+	}, pivot)
+}
+
+func (ss *ScopesSuite) TestGetPivotAggregations_countEmptyCells() {
+	nuid := util.UuidMust()
+	testObject := &TestObject{ID: uuid.Must(uuid.NewV4()), Nuid: nuid, Number: 1}
+	err := ss.DB.Create(testObject).Error
+	ss.NoError(err)
+
+	testObject = &TestObject{ID: uuid.Must(uuid.NewV4()), Number: 2}
+	err = ss.DB.Create(testObject).Error
+	ss.NoError(err)
+
+	// A COUNT of no rows is 0 rather than nil.
+	aggregation := scope.StandardAggregations[scope.StandardAggregationsTypeCount]
+	pivot, err := scope.GetPivotAggregations(context.Background(), ss.DB, &[]TestObject{}, "id", "null_id", "num", nil, aggregation)
+	ss.NoError(err)
+	ss.Equal(ss.nullRowsOrdered(&scope.PivotAggregation{
+		Name:          "count_id",
+		RowHeaders:    []interface{}{nuid, nulls.UUID{}},
+		ColumnHeaders: []interface{}{float64(1), float64(2)},
+		Values:        [][]interface{}{{1, 0}, {0, 1}},
+	}), pivot)
+}
+
+func (ss *ScopesSuite) TestGetPivotAggregations_empty() {
+	aggregation := scope.StandardAggregations[scope.StandardAggregationsTypeCount]
+	pivot, err := scope.GetPivotAggregations(context.Background(), ss.DB, &[]TestObject{}, "id", "null_id", "num", nil, aggregation)
+	ss.NoError(err)
+	ss.Equal(&scope.PivotAggregation{
+		Name:          "count_id",
+		RowHeaders:    []interface{}{},
+		ColumnHeaders: []interface{}{},
+		Values:        [][]interface{}{},
+	}, pivot)
+}
+
+func (ss *ScopesSuite) TestGetPivotAggregations_tooManyColumns() {
+	defaultPivotColumnsMax := scope.AggregationPivotColumnsMax
+	scope.AggregationPivotColumnsMax = 1
+	defer func() {
+		scope.AggregationPivotColumnsMax = defaultPivotColumnsMax
+	}()
+
+	for _, number := range []float64{1, 2} {
+		testObject := &TestObject{ID: uuid.Must(uuid.NewV4()), Number: number}
+		err := ss.DB.Create(testObject).Error
+		ss.NoError(err)
+	}
+
+	aggregation := scope.StandardAggregations[scope.StandardAggregationsTypeCount]
+	_, err := scope.GetPivotAggregations(context.Background(), ss.DB, &[]TestObject{}, "id", "null_id", "num", nil, aggregation)
+	ss.Error(err)
+}
+
+func (ss *ScopesSuite) TestGetPivotAggregationsFromParams_invalid() {
+	testCases := []map[string][]string{
+		{
+			"aggregation_column":         {"id|num"},
+			"aggregation_type":           {"count|sum"},
+			"aggregation_grouper_column": {"null_id"},
+			"aggregation_pivot_column":   {"num"},
+		},
+		{
+			"aggregation_column":         {"id"},
+			"aggregation_type":           {"count"},
+			"aggregation_grouper_column": {"null_id"},
+		},
+		{
+			"aggregation_column":         {"id"},
+			"aggregation_type":           {"count"},
+			"aggregation_grouper_column": {"null_id"},
+			"aggregation_pivot_column":   {"not_in_db"},
+		},
+	}
+
+	for _, params := range testCases {
+		_, err := scope.GetPivotAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+		ss.Error(err)
+	}
+}
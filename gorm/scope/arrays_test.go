@@ -0,0 +1,163 @@
+package scope_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/gobuffalo/pop/v5/slices"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+type TestArrayObject struct {
+	ID         uuid.UUID     `json:"id" db:"id" gorm:"primaryKey;column:id"`
+	Tags       slices.String `json:"tags" db:"tags" gorm:"column:tags;type:text[]"`
+	RelatedIDs slices.UUID   `json:"related_ids" db:"related_ids" gorm:"column:related_ids;type:uuid[]"`
+}
+
+func (t TestArrayObject) TableName() string {
+	return "array_objects"
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_arrays() {
+	ao := TestArrayObject{}
+	q := ss.dryRunDB("postgres").Model(ao)
+	q.Statement.SQL.Reset()
+	scopeQueryFunc := q.Find(&ao)
+	baseQuery := scopeQueryFunc.Statement.SQL.String()
+
+	testCases := []struct {
+		Name          string
+		Params        map[string][]string
+		ExpectErr     bool
+		ExpectedQuery string
+		ExpectedArgs  []string
+	}{
+		{
+			Name: "Contains Operator",
+			Params: map[string][]string{
+				"filter_columns": {"tags"},
+				"filter_types":   {"contains"},
+				"filter_values":  {"a,b"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (array_objects.tags @> $1)", baseQuery),
+			ExpectedArgs:  []string{`{"a","b"}`},
+		},
+		{
+			Name: "Contained By Operator",
+			Params: map[string][]string{
+				"filter_columns": {"tags"},
+				"filter_types":   {"contained_by"},
+				"filter_values":  {`a,"b\`},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (array_objects.tags <@ $1)", baseQuery),
+			ExpectedArgs:  []string{`{"a","\"b\\"}`},
+		},
+		{
+			Name: "Overlaps Operator",
+			Params: map[string][]string{
+				"filter_columns": {"related_ids"},
+				"filter_types":   {"overlaps"},
+				"filter_values":  {""},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (array_objects.related_ids && $1)", baseQuery),
+			ExpectedArgs:  []string{"{}"},
+		},
+		{
+			Name: "Any Operator",
+			Params: map[string][]string{
+				"filter_columns": {"tags"},
+				"filter_types":   {"any"},
+				"filter_values":  {"a"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE ($1 = ANY(array_objects.tags))", baseQuery),
+			ExpectedArgs:  []string{"a"},
+		},
+		{
+			Name: "Array Operator Without Array",
+			Params: map[string][]string{
+				"filter_columns": {"id"},
+				"filter_types":   {"contains"},
+				"filter_values":  {"a"},
+			},
+			ExpectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		ss.T().Run(testCase.Name, func(t *testing.T) {
+			s, err := scope.ForFiltersFromParams(context.Background(), ao, url.Values(testCase.Params))
+			if testCase.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			q := ss.dryRunDB("postgres").Model(ao)
+			q.Statement.SQL.Reset()
+			scopeQueryFunc := q.Scopes(s).Find(&ao)
+
+			args := scopeQueryFunc.Statement.Vars
+			query := scopeQueryFunc.Statement.SQL.String()
+			assert.Equal(t, testCase.ExpectedQuery, query)
+			assert.Equal(t, len(testCase.ExpectedArgs), len(args))
+
+			for i, arg := range testCase.ExpectedArgs {
+				assert.Equal(t, arg, args[i])
+			}
+		})
+	}
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_arraysQuery() {
+	ss.skipUnlessSupports("CONTAINS", "CONTAINED_BY", "OVERLAPS", "ANY")
+
+	relatedID := uuid.Must(uuid.NewV4())
+
+	first := &TestArrayObject{ID: uuid.Must(uuid.NewV4()), Tags: slices.String{"red", "blue"}, RelatedIDs: slices.UUID{relatedID}}
+	err := ss.DB.Create(first).Error
+	ss.NoError(err)
+
+	second := &TestArrayObject{ID: uuid.Must(uuid.NewV4()), Tags: slices.String{"red"}, RelatedIDs: slices.UUID{}}
+	err = ss.DB.Create(second).Error
+	ss.NoError(err)
+
+	testCases := []struct {
+		Column   string
+		Type     string
+		Value    string
+		Expected []uuid.UUID
+	}{
+		{Column: "tags", Type: "CONTAINS", Value: "red,blue", Expected: []uuid.UUID{first.ID}},
+		{Column: "tags", Type: "CONTAINED_BY", Value: "red,green", Expected: []uuid.UUID{second.ID}},
+		{Column: "tags", Type: "OVERLAPS", Value: "blue,green", Expected: []uuid.UUID{first.ID}},
+		{Column: "tags", Type: "ANY", Value: "red", Expected: []uuid.UUID{first.ID, second.ID}},
+		{Column: "related_ids", Type: "CONTAINS", Value: relatedID.String(), Expected: []uuid.UUID{first.ID}},
+	}
+
+	for _, testCase := range testCases {
+		params := url.Values{
+			"filter_columns": {testCase.Column},
+			"filter_types":   {testCase.Type},
+			"filter_values":  {testCase.Value},
+		}
+
+		s, err := scope.ForFiltersFromParams(context.Background(), TestArrayObject{}, params)
+		ss.NoError(err)
+
+		objects := []TestArrayObject{}
+		err = ss.DB.Scopes(s).Find(&objects).Error
+		ss.NoError(err)
+
+		ids := make([]uuid.UUID, len(objects))
+		for i := range objects {
+			ids[i] = objects[i].ID
+		}
+
+		ss.ElementsMatch(testCase.Expected, ids, testCase.Type)
+	}
+}
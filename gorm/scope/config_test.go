@@ -0,0 +1,165 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+func (ss *ScopesSuite) TestConfig_ParamKeys() {
+	config := scope.NewConfig()
+	config.ParamKeys = map[string]string{"filter_columns": "fc", "filter_types": "ft", "filter_values": "fv"}
+	ctx := scope.WithConfig(context.Background(), config)
+
+	s, err := scope.ForFiltersFromParams(ctx, TestModel{}, url.Values{"fc": {"id"}, "ft": {"nn"}, "fv": {""}})
+	ss.NoError(err)
+
+	var models []TestModel
+	q := ss.dryRunDB("postgres").Scopes(s).Find(&models)
+	ss.NoError(q.Error)
+	ss.Contains(q.Statement.SQL.String(), "test_models.id is not null")
+
+	// Errors name the params by their default names.
+	_, err = scope.ForFiltersFromParams(ctx, TestModel{}, url.Values{"fc": {"missing"}, "ft": {"eq"}, "fv": {"1"}})
+	var paramErr *scope.ParamError
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal("filter_columns", paramErr.Param)
+
+	ss.Equal("fc", config.ParamKey("filter_columns"))
+	ss.Equal("sort_columns", config.ParamKey("sort_columns"))
+}
+
+func (ss *ScopesSuite) TestConfig_Separators() {
+	config := scope.NewConfig()
+	config.FilterSeparator = ";"
+	config.FilterArgsSeparator = "~"
+	config.Limits = scope.Limits{MaxFilterValues: 1}
+	ctx := scope.WithConfig(context.Background(), config)
+
+	params := url.Values{"filter_columns": {"id;id"}, "filter_types": {"nn;in"}, "filter_values": {";a,b"}, "filter_logic": {"and"}}
+	_, err := scope.ForFiltersFromParams(ctx, TestModel{}, params)
+	ss.NoError(err)
+
+	params.Set("filter_values", ";a~b")
+	_, err = scope.ForFiltersFromParams(ctx, TestModel{}, params)
+	var paramErr *scope.ParamError
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeLimitExceeded, paramErr.Code)
+	ss.Equal(1, paramErr.Index)
+
+	// Requests can still override the separators.
+	params = url.Values{"filter_columns": {"id|id"}, "filter_types": {"nn|nn"}, "filter_values": {"|"}, "filter_logic": {"and"}, "filter_separator": {"|"}}
+	_, err = scope.ForFiltersFromParams(ctx, TestModel{}, params)
+	ss.NoError(err)
+}
+
+func (ss *ScopesSuite) TestConfig_Collection() {
+	config := scope.NewConfig()
+	config.Limits = scope.Limits{MaxAggregations: 1}
+	sc := scope.NewCollection(ss.DB).WithConfig(config)
+
+	params := url.Values{"aggregation_column": {"id|id"}, "aggregation_type": {"count|max"}}
+	_, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, params, sc)
+	ss.EqualError(err, "too many aggregations: more than 1")
+}
+
+func (ss *ScopesSuite) TestConfig_Namespace() {
+	params := url.Values{
+		"invoices[filter_columns]": {"id"},
+		"invoices[filter_types]":   {"nn"},
+		"invoices[filter_values]":  {""},
+		"invoices[page]":           {"2"},
+		"payments.filter_columns":  {"id"},
+		"payments.filter_types":    {"nu"},
+		"payments.filter_values":   {""},
+	}
+
+	s, err := scope.ForFiltersFromParams(scope.WithNamespace(context.Background(), "invoices"), TestModel{}, params)
+	ss.NoError(err)
+	var models []TestModel
+	q := ss.dryRunDB("postgres").Scopes(s, scope.NewConfig().WithNamespace("invoices").ForPaginateFromParams(params)).Find(&models)
+	ss.Contains(q.Statement.SQL.String(), "test_models.id is not null")
+	ss.Contains(q.Statement.SQL.String(), "LIMIT 20 OFFSET 20")
+
+	config := scope.NewConfig().WithNamespace("payments")
+	config.NamespaceFormat = scope.NamespaceFormatDots
+	s, err = scope.ForFiltersFromParams(scope.WithConfig(context.Background(), config), TestModel{}, params)
+	ss.NoError(err)
+	q = ss.dryRunDB("postgres").Scopes(s).Find(&models)
+	ss.Contains(q.Statement.SQL.String(), "test_models.id is null")
+
+	// Params outside of the namespace are ignored.
+	s, err = scope.ForFiltersFromParams(context.Background(), TestModel{}, params)
+	ss.NoError(err)
+	q = ss.dryRunDB("postgres").Scopes(s).Find(&models)
+	ss.NotContains(q.Statement.SQL.String(), "WHERE")
+
+	// Aggregations, filter facets and filter options are namespaced by the config of their collection.
+	sc := scope.NewCollection(ss.DB).WithConfig(scope.NewConfig().WithNamespace("invoices"))
+	params = url.Values{"invoices[aggregation_column]": {"id|id"}, "invoices[aggregation_type]": {"count|nope"}}
+	_, err = scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, params, sc)
+	ss.EqualError(err, "unknown aggregation type")
+
+	ss.Equal("invoices[fc]", (&scope.Config{ParamKeys: map[string]string{"filter_columns": "fc"}, Namespace: "invoices"}).ParamKey("filter_columns"))
+}
+
+func (ss *ScopesSuite) TestConfig_ForPaginateFromParams() {
+	config := scope.NewConfig()
+	config.ParamKeys = map[string]string{"page": "p", "per_page": "size"}
+	config.PerPageDefault = 5
+	config.PerPageMax = 50
+
+	testCases := []struct {
+		Params   url.Values
+		Expected string
+	}{
+		{url.Values{}, "LIMIT 5"},
+		{url.Values{"p": {"3"}, "size": {"10"}}, "LIMIT 10 OFFSET 20"},
+		{url.Values{"p": {"0"}, "size": {"500"}}, "LIMIT 50"},
+		{url.Values{"p": {"x"}, "size": {"-1"}}, "LIMIT 5"},
+	}
+
+	for _, tc := range testCases {
+		var models []TestModel
+		q := ss.dryRunDB("postgres").Scopes(config.ForPaginateFromParams(tc.Params)).Find(&models)
+		ss.NoError(q.Error)
+		ss.True(strings.HasSuffix(q.Statement.SQL.String(), tc.Expected), q.Statement.SQL.String())
+	}
+
+	// Paginate limits the results per page to 100 by default.
+	var models []TestModel
+	q := ss.dryRunDB("postgres").Scopes(scope.Paginate(2, 500)).Find(&models)
+	ss.True(strings.HasSuffix(q.Statement.SQL.String(), "LIMIT 100 OFFSET 100"), q.Statement.SQL.String())
+}
+
+func (ss *ScopesSuite) TestConfigFromContext() {
+	config := scope.ConfigFromContext(context.Background())
+	ss.Equal("|", config.FilterSeparator)
+	ss.Equal(scope.PostgresDialect, config.Dialect)
+	ss.Equal(time.UTC, config.TimeZone)
+
+	// Contexts without a config share the default config.
+	ss.Same(config, scope.ConfigFromContext(context.Background()))
+
+	// The default config follows the package defaults.
+	defer func(limit int) { scope.FilterOptionsLimitDefault = limit }(scope.FilterOptionsLimitDefault)
+	scope.FilterOptionsLimitDefault = 7
+	ss.Equal(7, scope.ConfigFromContext(context.Background()).FilterOptionsLimitDefault)
+	ss.NotSame(config, scope.ConfigFromContext(context.Background()))
+
+	config = scope.NewConfig()
+	config.Dialect = scope.MySQLDialect
+	config.Limits = scope.Limits{MaxSortColumns: 1}
+	ctx := scope.WithConfig(context.Background(), config)
+	ss.Equal(scope.MySQLDialect, scope.DialectFromContext(ctx))
+	ss.Equal(config.Limits, scope.LimitsFromContext(ctx))
+
+	// WithDialect and WithLimits override the config.
+	ss.Equal(scope.SQLiteDialect, scope.DialectFromContext(scope.WithDialect(ctx, scope.SQLiteDialect)))
+	ss.Equal(scope.Limits{}, scope.LimitsFromContext(scope.WithLimits(ctx, scope.Limits{})))
+}
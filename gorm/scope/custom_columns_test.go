@@ -3,6 +3,7 @@ package scope_test
 import (
 	"context"
 	"reflect"
+	"time"
 
 	"github.com/gobuffalo/nulls"
 	"github.com/gofrs/uuid"
@@ -81,7 +82,7 @@ func (ss *ScopesSuite) TestGetAllFilterColumnNames() {
 	ss.NoError(err)
 
 	expectedFilters := []string{"id", "null_id", "num", "custom_filter", "custom_uuid_filter", "custom_nulls_uuid_filter", "null_filter"}
-	ss.ElementsMatch(expectedFilters, filters)
+	ss.Equal(expectedFilters, filters)
 }
 
 func (ss *ScopesSuite) TestGetAllSortColumns() {
@@ -127,7 +128,7 @@ func (ss *ScopesSuite) TestGetAllSortColumnNames() {
 	ss.NoError(err)
 
 	expectedSorts := []string{"id", "null_id", "num", "custom_sort", "custom_uuid_sort", "custom_nulls_uuid_sort", "null_sort"}
-	ss.ElementsMatch(expectedSorts, sorts)
+	ss.Equal(expectedSorts, sorts)
 }
 
 func (ss *ScopesSuite) TestGenerateSubobjectCustomFilterColumns() {
@@ -151,3 +152,59 @@ func (ss *ScopesSuite) TestGenerateSubobjectCustomFilterColumns() {
 
 	ss.Equal(expectedCustomFilterColumns, customFilterColumns)
 }
+
+func (ss *ScopesSuite) TestCustomColumnsWithLabelColumn() {
+	testSubObject := &TestSubObject{}
+
+	customFilterColumns, err := scope.GenerateCustomColumnsForSubobject(testSubObject, "subobject", "id = subobject.object_id")
+	ss.NoError(err)
+
+	labeledColumns, err := customFilterColumns.WithLabelColumn("subobject.id", "subobject.object_id")
+	ss.NoError(err)
+	ss.Equal("(select object_id from test_sub_objects where id = subobject.object_id)", labeledColumns[0].LabelStatement)
+	ss.Empty(labeledColumns[1].LabelStatement)
+
+	// The original columns are not modified.
+	ss.Empty(customFilterColumns[0].LabelStatement)
+
+	_, err = customFilterColumns.WithLabelColumn("subobject.id", "not_a_column")
+	ss.Error(err)
+
+	_, err = customFilterColumns.WithLabelColumn("not_a_column", "subobject.id")
+	ss.Error(err)
+}
+
+type TestDisplayedObject struct {
+	ID         uuid.UUID `json:"id" db:"id" gorm:"primaryKey;column:id"`
+	Number     float64   `json:"num" db:"num" gorm:"column:num"`
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at" gorm:"column:occurred_at"`
+}
+
+func (t TestDisplayedObject) TableName() string {
+	return "dated_objects"
+}
+
+func (t TestDisplayedObject) GetCustomFilters(ctx context.Context) scope.CustomColumns {
+	return scope.CustomColumns{
+		{Name: "num", Statement: "dated_objects.num", ResultType: reflect.TypeOf(float64(0)), DisplayGroup: "numbers"},
+		{Name: "occurred_date", Statement: "dated_objects.occurred_at::DATE", ResultType: reflect.TypeOf(time.Time{})},
+		{Name: "first", Statement: "NULL", ResultType: reflect.TypeOf(""), DisplayOrder: -1},
+		{Name: "num_text", Statement: "dated_objects.num::TEXT", ResultType: reflect.TypeOf(""), DisplayGroup: "numbers"},
+		{Name: "last", Statement: "NULL", ResultType: reflect.TypeOf(""), DisplayOrder: 1},
+	}
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumnNames_DisplayOrder() {
+	filters, err := scope.GetAllFilterColumnNames(context.Background(), &TestDisplayedObject{})
+	ss.NoError(err)
+
+	expectedFilters := []string{"first", "id", "num", "num_text", "occurred_at", "occurred_date", "last"}
+	ss.Equal(expectedFilters, filters)
+
+	// The order is the same for every call.
+	for i := 0; i < 10; i++ {
+		filters, err = scope.GetAllFilterColumnNames(context.Background(), &TestDisplayedObject{})
+		ss.NoError(err)
+		ss.Equal(expectedFilters, filters)
+	}
+}
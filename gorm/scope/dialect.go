@@ -0,0 +1,316 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Features that are only supported by some dialects, in addition to the filter types of filterTypes.
+const (
+	// FeatureJSONPaths is filtering on paths within JSONB columns, see getJSONPathStatement.
+	FeatureJSONPaths = "JSON_PATHS"
+	// FeatureSearch is full text search, see ForSearchFromParams.
+	FeatureSearch = "SEARCH"
+	// FeatureGroupingSets is GROUP BY GROUPING SETS, which is required by filter facets.
+	FeatureGroupingSets = "GROUPING_SETS"
+	// FeatureQueryCost is the planner cost of EXPLAIN (FORMAT JSON), which is required by CheckQueryCost.
+	FeatureQueryCost = "QUERY_COST"
+)
+
+// Dialect generates the SQL that differs between databases.  The dialect of a query is detected from its database,
+// see getDialect.
+type Dialect interface {
+	// Name returns the name of the dialect, ex. `postgres`.
+	Name() string
+
+	// Supports returns true if the dialect supports a filter type, or one of the features above.
+	Supports(feature string) bool
+
+	// Comparison returns the clause comparing a statement to one arg by a filter type, ex. `statement = ?`.
+	Comparison(filterType, statement string) string
+
+	// Cast returns a statement cast to a type, which is the name of the postgres type, ex. `TEXT`.
+	Cast(statement, sqlType string) string
+
+	// Quote returns a quoted identifier, ex. `"name"`.
+	Quote(identifier string) string
+
+	// Placeholder returns the placeholder of the arg at an index, starting at 1.
+	Placeholder(index int) string
+}
+
+// PostgresDialect is the default dialect, which supports every filter type and feature.
+var PostgresDialect Dialect = postgresDialect{}
+
+// MySQLDialect is the dialect of MySQL 8, which does not support the postgres specific filter types and features.
+var MySQLDialect Dialect = mysqlDialect{}
+
+// SQLiteDialect is the dialect of SQLite, which does not support the postgres specific filter types and features.
+var SQLiteDialect Dialect = sqliteDialect{}
+
+// dialects are the dialects of each pop and gorm driver name.
+var dialects = map[string]Dialect{
+	"postgres":  PostgresDialect,
+	"cockroach": PostgresDialect,
+	"mysql":     MySQLDialect,
+	"sqlite":    SQLiteDialect,
+	"sqlite3":   SQLiteDialect,
+}
+
+// postgresOnlyFeatures are the filter types and features that are only supported by postgres.
+var postgresOnlyFeatures = map[string]bool{
+	"SIM":               true,
+	"CT":                true,
+	"HK":                true,
+	"CONTAINS":          true,
+	"CONTAINED_BY":      true,
+	"OVERLAPS":          true,
+	"ANY":               true,
+	FeatureJSONPaths:    true,
+	FeatureSearch:       true,
+	FeatureGroupingSets: true,
+	FeatureQueryCost:    true,
+}
+
+// getDialect returns the dialect of a database, which is postgres if it is unknown.
+func getDialect(db *gorm.DB) Dialect {
+	if db == nil || db.Dialector == nil {
+		return PostgresDialect
+	}
+
+	if dialect, ok := dialects[db.Dialector.Name()]; ok {
+		return dialect
+	}
+
+	return PostgresDialect
+}
+
+// GetDialect returns the dialect of a database, which is postgres if it is unknown.  Scopes built from params can be
+// built for the dialect of the database that they are applied to with `WithDialect(ctx, GetDialect(tx))`, so that a
+// filter or sort that the dialect does not support is returned as an error.
+func GetDialect(db *gorm.DB) Dialect {
+	return getDialect(db)
+}
+
+// dialectContextKey is the context key of the dialect set by WithDialect.
+type dialectContextKey struct{}
+
+// WithDialect returns a copy of a context in which columns are generated for a dialect, see DialectFromContext.
+func WithDialect(ctx context.Context, dialect Dialect) context.Context {
+	return context.WithValue(ctx, dialectContextKey{}, dialect)
+}
+
+// DialectFromContext returns the dialect that columns are generated for within a context, which is the Dialect of the
+// config of the context unless it is set by WithDialect.  Functions that are passed a connection set the dialect of the connection themselves, and
+// custom columns can use it to quote their identifiers, ex. `QuoteIdentifier(DialectFromContext(ctx), "order")`.
+func DialectFromContext(ctx context.Context) Dialect {
+	if ctx != nil {
+		if dialect, ok := ctx.Value(dialectContextKey{}).(Dialect); ok && dialect != nil {
+			return dialect
+		}
+	}
+
+	if dialect := ConfigFromContext(ctx).Dialect; dialect != nil {
+		return dialect
+	}
+
+	return PostgresDialect
+}
+
+// unquotedIdentifierRegex matches the identifiers that do not need to be quoted in any dialect.
+var unquotedIdentifierRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// reservedWords are the keywords that are reserved by any of the dialects, which must be quoted to be identifiers.
+var reservedWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		all analyse analyze and any array as asc asymmetric authorization between binary both by case cast check
+		collate column concurrently constraint create cross current_catalog current_date current_role current_schema
+		current_time current_timestamp current_user default deferrable delete desc distinct do drop else end except
+		exists false fetch for foreign freeze from full grant group having ilike in index initially inner insert
+		intersect interval into is isnull join key keys lateral leading left like limit localtime localtimestamp
+		match natural not notnull null offset on only or order outer overlaps placing primary range rank read
+		references regexp replace returning right row rows select session_user set similar some symmetric table
+		tablesample then to trailing true union unique update usage user using values variadic verbose when where
+		window with`) {
+		reservedWords[word] = true
+	}
+}
+
+// QuoteIdentifier quotes each part of an identifier, which may be qualified by a schema or table, ex.
+// `billing.invoices`.  Like the postgres quote_ident function, parts are only quoted if they are reserved words, or
+// would otherwise be changed or rejected by the database, ex. mixed case names.  Identifiers that contain quotes are
+// assumed to already be quoted, and are returned unchanged.
+func QuoteIdentifier(dialect Dialect, identifier string) string {
+	if strings.ContainsAny(identifier, "\"`") {
+		return identifier
+	}
+
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifierPart(dialect, part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// quoteIdentifierPart quotes one part of an identifier, if it needs to be quoted.
+func quoteIdentifierPart(dialect Dialect, part string) string {
+	if unquotedIdentifierRegex.MatchString(part) && !reservedWords[part] {
+		return part
+	}
+
+	return dialect.Quote(part)
+}
+
+// quoteColumn returns the column of a table, quoted as needed, ex. `invoices."order"`.  The column is a single
+// identifier, which is quoted as a whole even if it contains a period.
+func quoteColumn(dialect Dialect, tableName, column string) string {
+	return fmt.Sprintf("%v.%v", QuoteIdentifier(dialect, tableName), quoteIdentifierPart(dialect, column))
+}
+
+// fromTable returns a table to select from, quoted as needed.
+func fromTable(dialect Dialect, tableName string) string {
+	return QuoteIdentifier(dialect, tableName)
+}
+
+// comparison returns the clause of a filter type that is the same in every dialect.
+func comparison(filterType, statement string) string {
+	return fmt.Sprintf("%s %s ?", statement, filterTypes[strings.ToUpper(filterType)])
+}
+
+type postgresDialect struct{}
+
+func (d postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (d postgresDialect) Supports(feature string) bool {
+	return true
+}
+
+func (d postgresDialect) Comparison(filterType, statement string) string {
+	return comparison(filterType, statement)
+}
+
+func (d postgresDialect) Cast(statement, sqlType string) string {
+	return fmt.Sprintf("CAST(%s AS %s)", statement, sqlType)
+}
+
+func (d postgresDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (d postgresDialect) Placeholder(index int) string {
+	return fmt.Sprintf("$%v", index)
+}
+
+type mysqlDialect struct{}
+
+// mysqlCastTypes are the MySQL types of the postgres types that differ.
+var mysqlCastTypes = map[string]string{
+	"TEXT":        "CHAR",
+	"NUMERIC":     "DECIMAL(65,30)",
+	"INTEGER":     "SIGNED",
+	"BIGINT":      "SIGNED",
+	"BOOLEAN":     "UNSIGNED",
+	"TIMESTAMP":   "DATETIME",
+	"TIMESTAMPTZ": "DATETIME",
+}
+
+func (d mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (d mysqlDialect) Supports(feature string) bool {
+	return !postgresOnlyFeatures[strings.ToUpper(feature)]
+}
+
+func (d mysqlDialect) Comparison(filterType, statement string) string {
+	switch strings.ToUpper(filterType) {
+	case "ILK":
+		return fmt.Sprintf("LOWER(%s) LIKE LOWER(?)", statement)
+	case "NILK":
+		return fmt.Sprintf("LOWER(%s) NOT LIKE LOWER(?)", statement)
+	case "DF":
+		return fmt.Sprintf("NOT (%s <=> ?)", statement)
+	case "NDF":
+		return fmt.Sprintf("%s <=> ?", statement)
+	default:
+		return comparison(filterType, statement)
+	}
+}
+
+func (d mysqlDialect) Cast(statement, sqlType string) string {
+	if mysqlType, ok := mysqlCastTypes[strings.ToUpper(sqlType)]; ok {
+		sqlType = mysqlType
+	}
+
+	return fmt.Sprintf("CAST(%s AS %s)", statement, sqlType)
+}
+
+func (d mysqlDialect) Quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func (d mysqlDialect) Placeholder(index int) string {
+	return "?"
+}
+
+type sqliteDialect struct{}
+
+func (d sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (d sqliteDialect) Supports(feature string) bool {
+	return !postgresOnlyFeatures[strings.ToUpper(feature)]
+}
+
+// Comparison escapes the wildcards of LIKE patterns with a backslash, as postgres and MySQL do by default, since SQLite
+// has no default escape character.
+func (d sqliteDialect) Comparison(filterType, statement string) string {
+	switch strings.ToUpper(filterType) {
+	case "LK":
+		return fmt.Sprintf(`%s LIKE ? ESCAPE '\'`, statement)
+	case "NLK":
+		return fmt.Sprintf(`%s NOT LIKE ? ESCAPE '\'`, statement)
+	case "ILK":
+		return fmt.Sprintf(`LOWER(%s) LIKE LOWER(?) ESCAPE '\'`, statement)
+	case "NILK":
+		return fmt.Sprintf(`LOWER(%s) NOT LIKE LOWER(?) ESCAPE '\'`, statement)
+	case "DF":
+		return fmt.Sprintf("%s IS NOT ?", statement)
+	case "NDF":
+		return fmt.Sprintf("%s IS ?", statement)
+	default:
+		return comparison(filterType, statement)
+	}
+}
+
+// Cast casts dates and times with the SQLite date functions, since SQLite stores them as text.
+func (d sqliteDialect) Cast(statement, sqlType string) string {
+	switch strings.ToUpper(sqlType) {
+	case "DATE":
+		return fmt.Sprintf("DATE(%s)", statement)
+	case "TIMESTAMP", "TIMESTAMPTZ":
+		return fmt.Sprintf("DATETIME(%s)", statement)
+	case "BOOLEAN":
+		sqlType = "INTEGER"
+	}
+
+	return fmt.Sprintf("CAST(%s AS %s)", statement, sqlType)
+}
+
+func (d sqliteDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (d sqliteDialect) Placeholder(index int) string {
+	return "?"
+}
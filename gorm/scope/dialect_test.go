@@ -0,0 +1,213 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/gofrs/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+// testDialector renames a dialector, so that SQL can be built for other dialects without their drivers.
+type testDialector struct {
+	gorm.Dialector
+	name string
+}
+
+func (d testDialector) Name() string {
+	return d.name
+}
+
+// dryRunDB returns a database that only builds SQL, as if it were the named dialect.  The placeholders and the quoting
+// of gorm itself are still those of postgres.
+func (ss *ScopesSuite) dryRunDB(name string) *gorm.DB {
+	db, err := gorm.Open(testDialector{Dialector: postgres.New(postgres.Config{DSN: "host=localhost"}), name: name}, &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	ss.NoError(err)
+	return db
+}
+
+func (ss *ScopesSuite) TestDialect_Comparison() {
+	testCases := []struct {
+		Dialect    scope.Dialect
+		FilterType string
+		Expected   string
+	}{
+		{scope.PostgresDialect, "eq", "name = ?"},
+		{scope.PostgresDialect, "ilk", "name ilike ?"},
+		{scope.PostgresDialect, "df", "name is distinct from ?"},
+		{scope.MySQLDialect, "eq", "name = ?"},
+		{scope.MySQLDialect, "ilk", "LOWER(name) LIKE LOWER(?)"},
+		{scope.MySQLDialect, "nilk", "LOWER(name) NOT LIKE LOWER(?)"},
+		{scope.MySQLDialect, "df", "NOT (name <=> ?)"},
+		{scope.MySQLDialect, "ndf", "name <=> ?"},
+		{scope.SQLiteDialect, "lt", "name < ?"},
+		{scope.SQLiteDialect, "ilk", `LOWER(name) LIKE LOWER(?) ESCAPE '\'`},
+		{scope.SQLiteDialect, "nlk", `name NOT LIKE ? ESCAPE '\'`},
+		{scope.SQLiteDialect, "df", "name IS NOT ?"},
+		{scope.SQLiteDialect, "ndf", "name IS ?"},
+	}
+
+	for _, tc := range testCases {
+		ss.Equal(tc.Expected, tc.Dialect.Comparison(tc.FilterType, "name"), tc.Dialect.Name()+" "+tc.FilterType)
+	}
+}
+
+func (ss *ScopesSuite) TestDialect_Cast() {
+	ss.Equal("CAST(name AS TEXT)", scope.PostgresDialect.Cast("name", "TEXT"))
+	ss.Equal("CAST(name AS CHAR)", scope.MySQLDialect.Cast("name", "TEXT"))
+	ss.Equal("CAST(name AS DATETIME)", scope.MySQLDialect.Cast("name", "TIMESTAMPTZ"))
+	ss.Equal("CAST(name AS TEXT)", scope.SQLiteDialect.Cast("name", "TEXT"))
+	ss.Equal("DATE(name)", scope.SQLiteDialect.Cast("name", "DATE"))
+}
+
+func (ss *ScopesSuite) TestDialect_QuoteAndPlaceholder() {
+	ss.Equal(`"na""me"`, scope.PostgresDialect.Quote(`na"me`))
+	ss.Equal("`na``me`", scope.MySQLDialect.Quote("na`me"))
+	ss.Equal(`"name"`, scope.SQLiteDialect.Quote("name"))
+
+	ss.Equal("$2", scope.PostgresDialect.Placeholder(2))
+	ss.Equal("?", scope.MySQLDialect.Placeholder(2))
+	ss.Equal("?", scope.SQLiteDialect.Placeholder(2))
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_SQLite() {
+	params := url.Values{
+		"filter_columns": {"custom_filter|id"},
+		"filter_types":   {"ilk|df"},
+		"filter_values":  {"12%|00000000-0000-0000-0000-000000000000"},
+		"filter_logic":   {"and"},
+	}
+
+	s, err := scope.ForFiltersFromParams(context.Background(), TestModel{}, params)
+	ss.NoError(err)
+
+	var models []TestModel
+	q := ss.dryRunDB("sqlite").Scopes(s).Find(&models)
+	ss.NoError(q.Error)
+	ss.Contains(q.Statement.SQL.String(), `WHERE (LOWER((SELECT '1234')) LIKE LOWER($1) ESCAPE '\' AND test_models.id IS NOT $2)`)
+	ss.Len(q.Statement.Vars, 2)
+
+	// Postgres specific filter types are not supported.
+	params.Set("filter_types", "sim|df")
+	s, err = scope.ForFiltersFromParams(context.Background(), TestModel{}, params)
+	ss.NoError(err)
+
+	q = ss.dryRunDB("sqlite").Scopes(s).Find(&models)
+	ss.Error(q.Error)
+}
+
+func (ss *ScopesSuite) TestForSortFromParams_MySQL() {
+	params := url.Values{
+		"sort_columns":    {"id"},
+		"sort_directions": {"sim"},
+		"sort_values":     {"test"},
+	}
+
+	s, err := scope.ForSortFromParams(context.Background(), TestModel{}, params)
+	ss.NoError(err)
+
+	var models []TestModel
+	q := ss.dryRunDB("mysql").Scopes(s).Find(&models)
+	ss.Error(q.Error)
+
+	params.Set("sort_directions", "asc")
+	s, err = scope.ForSortFromParams(context.Background(), TestModel{}, params)
+	ss.NoError(err)
+
+	q = ss.dryRunDB("mysql").Scopes(s).Find(&models)
+	ss.NoError(q.Error)
+}
+
+func (ss *ScopesSuite) TestForSearchFromParams_MySQL() {
+	s, err := scope.ForSearchFromParams(context.Background(), TestDocument{}, url.Values{"q": {"test"}})
+	ss.NoError(err)
+
+	var documents []TestDocument
+	q := ss.dryRunDB("mysql").Scopes(s).Find(&documents)
+	ss.Error(q.Error)
+}
+
+type TestReservedObject struct {
+	ID       uuid.UUID `json:"id" gorm:"primaryKey"`
+	Order    int       `json:"order" gorm:"column:order"`
+	Group    string    `json:"group" gorm:"column:group"`
+	UserName string    `json:"user_name" gorm:"column:UserName"`
+}
+
+func (t TestReservedObject) TableName() string {
+	return "billing.invoices"
+}
+
+func (ss *ScopesSuite) TestQuoteIdentifier() {
+	ss.Equal("invoices", scope.QuoteIdentifier(scope.PostgresDialect, "invoices"))
+	ss.Equal("billing.invoices", scope.QuoteIdentifier(scope.PostgresDialect, "billing.invoices"))
+	ss.Equal(`"order"`, scope.QuoteIdentifier(scope.PostgresDialect, "order"))
+	ss.Equal(`billing."User"`, scope.QuoteIdentifier(scope.PostgresDialect, "billing.User"))
+	ss.Equal("`group`", scope.QuoteIdentifier(scope.MySQLDialect, "group"))
+	ss.Equal(`"already quoted"`, scope.QuoteIdentifier(scope.PostgresDialect, `"already quoted"`))
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_Quoted() {
+	filters, err := scope.GetAllFilterColumns(context.Background(), &TestReservedObject{})
+	ss.NoError(err)
+
+	filtersMap := make(map[string]scope.CustomColumn, len(filters))
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+
+	ss.Equal("billing.invoices.id", filtersMap["id"].Statement)
+	ss.Equal(`billing.invoices."order"`, filtersMap["order"].Statement)
+	ss.Equal(`billing.invoices."group"`, filtersMap["group"].Statement)
+	ss.Equal(`billing.invoices."UserName"`, filtersMap["user_name"].Statement)
+
+	filters, err = scope.GetAllFilterColumns(scope.WithDialect(context.Background(), scope.MySQLDialect), &TestReservedObject{})
+	ss.NoError(err)
+
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+
+	ss.Equal("billing.invoices.`order`", filtersMap["order"].Statement)
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_ReservedWords() {
+	params := url.Values{
+		"filter_columns":  {"order|user_name"},
+		"filter_types":    {"gt|eq"},
+		"filter_values":   {"1|test"},
+		"filter_logic":    {"and"},
+		"sort_columns":    {"group"},
+		"sort_directions": {"asc"},
+	}
+
+	filters, err := scope.ForFiltersFromParams(context.Background(), TestReservedObject{}, params)
+	ss.NoError(err)
+
+	sorts, err := scope.ForSortFromParams(context.Background(), TestReservedObject{}, params)
+	ss.NoError(err)
+
+	var objects []TestReservedObject
+	q := ss.dryRunDB("postgres").Scopes(filters, sorts).Find(&objects)
+	ss.NoError(q.Error)
+	ss.Contains(q.Statement.SQL.String(), `FROM "billing"."invoices"`)
+	ss.Contains(q.Statement.SQL.String(), `WHERE (billing.invoices."order" > $1 AND billing.invoices."UserName" = $2)`)
+	ss.Contains(q.Statement.SQL.String(), `ORDER BY billing.invoices."group" ASC`)
+
+	// Columns are quoted for the dialect of the query.
+	q = ss.dryRunDB("mysql").Scopes(filters, sorts).Find(&objects)
+	ss.NoError(q.Error)
+	ss.Contains(q.Statement.SQL.String(), "WHERE (billing.invoices.`order` > $1 AND billing.invoices.`UserName` = $2)")
+	ss.Contains(q.Statement.SQL.String(), "ORDER BY billing.invoices.`group` ASC")
+
+	id := "5c2d7cfe-3d5b-4c4a-b3e5-9b1f9cbb5b2a"
+	q = ss.dryRunDB("postgres").Scopes(scope.ForIDForModel(id, TestReservedObject{})).Find(&objects)
+	ss.NoError(q.Error)
+	ss.Contains(q.Statement.SQL.String(), `WHERE billing.invoices.id = $1`)
+}
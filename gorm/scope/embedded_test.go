@@ -0,0 +1,145 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+type TestEmbeddedBase struct {
+	ID     uuid.UUID `json:"id" db:"id" gorm:"primaryKey;column:id"`
+	Number float64   `json:"num" db:"num" gorm:"column:num"`
+}
+
+type TestOccurrence struct {
+	At time.Time `json:"at" db:"at" gorm:"column:at"`
+}
+
+type TestEmbeddedObject struct {
+	TestEmbeddedBase
+	Number     int            `json:"number" db:"num" gorm:"-"`
+	Occurrence TestOccurrence `json:"occurrence" db:"-" gorm:"embedded;embeddedPrefix:occurred_"`
+}
+
+func (t TestEmbeddedObject) TableName() string {
+	return "dated_objects"
+}
+
+type TestShadowedEmbeddedObject struct {
+	*TestEmbeddedBase
+	Number string `json:"num" db:"num" gorm:"column:num"`
+}
+
+func (t TestShadowedEmbeddedObject) TableName() string {
+	return "dated_objects"
+}
+
+type TestValuedBase struct {
+	Value float64 `json:",omitempty" db:"num" gorm:"column:num"`
+}
+
+type TestOtherValuedBase struct {
+	Value float64 `json:",omitempty" db:"other_num" gorm:"column:other_num"`
+}
+
+type TestCollidingEmbeddedObject struct {
+	TestEmbeddedBase
+	TestValuedBase
+	TestOtherValuedBase
+}
+
+func (t TestCollidingEmbeddedObject) TableName() string {
+	return "dated_objects"
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_Embedded() {
+	filters, err := scope.GetAllFilterColumns(context.Background(), &TestEmbeddedObject{})
+	ss.NoError(err)
+
+	filtersMap := make(map[string]scope.CustomColumn, len(filters))
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+
+	ss.Len(filtersMap, 4)
+	ss.Equal("dated_objects.id", filtersMap["id"].Statement)
+	ss.Equal(reflect.TypeOf(uuid.UUID{}), filtersMap["id"].ResultType)
+	ss.Equal("dated_objects.num", filtersMap["num"].Statement)
+	ss.Equal(reflect.TypeOf(float64(0)), filtersMap["num"].ResultType)
+	ss.Equal("dated_objects.num", filtersMap["number"].Statement)
+	ss.Equal(reflect.TypeOf(0), filtersMap["number"].ResultType)
+	ss.Equal("dated_objects.occurred_at", filtersMap["occurrence.at"].Statement)
+	ss.Equal(reflect.TypeOf(time.Time{}), filtersMap["occurrence.at"].ResultType)
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_EmbeddedShadowed() {
+	filters, err := scope.GetAllFilterColumns(context.Background(), &TestShadowedEmbeddedObject{})
+	ss.NoError(err)
+
+	filtersMap := make(map[string]scope.CustomColumn, len(filters))
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+
+	ss.Len(filtersMap, 2)
+	ss.Equal("dated_objects.id", filtersMap["id"].Statement)
+	ss.Equal(reflect.TypeOf(""), filtersMap["num"].ResultType)
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_EmbeddedCollision() {
+	_, err := scope.GetAllFilterColumns(context.Background(), &TestCollidingEmbeddedObject{})
+	ss.Error(err)
+	ss.Contains(err.Error(), "Value")
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_Embedded() {
+	params := map[string][]string{
+		"filter_columns": {"id|occurrence.at"},
+		"filter_types":   {"nn|gt"},
+		"filter_values":  {"|2021-01-01"},
+		"filter_logic":   {"and"},
+	}
+
+	_, err := scope.ForFiltersFromParams(context.Background(), TestEmbeddedObject{}, url.Values(params))
+	ss.NoError(err)
+
+	_, err = scope.ForFiltersFromParams(context.Background(), TestCollidingEmbeddedObject{}, url.Values(params))
+	ss.Error(err)
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_EmbeddedQuery() {
+	ss.createDatedObjects(map[string][]float64{
+		"2021-01-15": {1, 2},
+		"2021-02-15": {3},
+	})
+
+	params := map[string][]string{
+		"filter_columns": {"occurrence.at|num"},
+		"filter_types":   {"gt|gte"},
+		"filter_values":  {"2021-02-01|2"},
+		"filter_logic":   {"or"},
+	}
+
+	s, err := scope.ForFiltersFromParams(context.Background(), TestEmbeddedObject{}, url.Values(params))
+	ss.NoError(err)
+
+	var objects []TestEmbeddedObject
+	err = ss.DB.Scopes(s).Find(&objects).Error
+	ss.NoError(err)
+	ss.Len(objects, 2)
+
+	for _, object := range objects {
+		ss.NotEqual(uuid.Nil, object.ID)
+		ss.False(object.Occurrence.At.IsZero())
+	}
+
+	q := ss.dryRunDB("postgres").Model(&TestEmbeddedObject{})
+	q.Statement.SQL.Reset()
+	q = q.Scopes(s).Find(&objects)
+	ss.Contains(q.Statement.SQL.String(), "dated_objects.occurred_at >")
+}
@@ -0,0 +1,166 @@
+package scope_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+func (ss *ScopesSuite) TestParamError_Filters() {
+	testCases := []struct {
+		Params url.Values
+		Code   scope.ParamErrorCode
+		Param  string
+		Index  int
+		Value  string
+	}{
+		{
+			Params: url.Values{"filter_columns": {"id|id"}, "filter_types": {"eq"}, "filter_values": {"1|2"}, "filter_logic": {"and"}},
+			Code:   scope.ParamErrorCodeMismatchedParams,
+			Index:  -1,
+		},
+		{
+			Params: url.Values{"filter_columns": {"id|missing"}, "filter_types": {"eq|eq"}, "filter_values": {"1|2"}, "filter_logic": {"and"}},
+			Code:   scope.ParamErrorCodeInvalidField,
+			Param:  "filter_columns",
+			Index:  1,
+			Value:  "missing",
+		},
+		{
+			Params: url.Values{"filter_columns": {"id"}, "filter_types": {"nope"}, "filter_values": {"1"}},
+			Code:   scope.ParamErrorCodeInvalidType,
+			Param:  "filter_types",
+			Index:  0,
+			Value:  "nope",
+		},
+		{
+			Params: url.Values{"filter_columns": {"id|id"}, "filter_types": {"eq|eq"}, "filter_values": {"1|2"}, "filter_logic": {"xor"}},
+			Code:   scope.ParamErrorCodeInvalidType,
+			Param:  "filter_logic",
+			Index:  0,
+			Value:  "xor",
+		},
+		{
+			Params: url.Values{"filter_columns": {"id"}, "filter_types": {"eq"}, "filter_values": {"1"}, "filter_left_parens": {"3"}, "filter_right_parens": {"0"}},
+			Code:   scope.ParamErrorCodeInvalidValue,
+			Param:  "filter_left_parens",
+			Index:  0,
+			Value:  "3",
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := scope.ForFiltersFromParams(context.Background(), TestModel{}, tc.Params)
+		ss.Error(err)
+
+		var paramErr *scope.ParamError
+		ss.True(errors.As(err, &paramErr), err.Error())
+		ss.Equal(tc.Code, paramErr.Code, err.Error())
+		ss.Equal(tc.Param, paramErr.Param, err.Error())
+		ss.Equal(tc.Index, paramErr.Index, err.Error())
+		ss.Equal(tc.Value, paramErr.Value, err.Error())
+	}
+}
+
+func (ss *ScopesSuite) TestParamError_Sorts() {
+	params := url.Values{"sort_columns": {"id|missing"}, "sort_directions": {"asc|desc"}}
+	_, err := scope.ForSortFromParams(context.Background(), TestModel{}, params)
+	ss.EqualError(err, "invalid sort field: missing")
+
+	var paramErr *scope.ParamError
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeInvalidField, paramErr.Code)
+	ss.Equal("sort_columns", paramErr.Param)
+	ss.Equal(1, paramErr.Index)
+	ss.Equal("missing", paramErr.Value)
+
+	// Errors of helpers are placed at the clause which caused them.
+	params = url.Values{"sort_columns": {"id|relevance"}, "sort_directions": {"asc|desc"}}
+	_, err = scope.ForSortFromParams(context.Background(), TestDocument{}, params)
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeUnsupported, paramErr.Code)
+	ss.Equal("sort_columns", paramErr.Param)
+	ss.Equal(1, paramErr.Index)
+}
+
+func (ss *ScopesSuite) TestParamError_Aggregations() {
+	params := url.Values{"aggregation_column": {"id|id"}, "aggregation_type": {"count|nope"}}
+	_, err := scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, params, nil)
+	ss.EqualError(err, "unknown aggregation type")
+
+	var paramErr *scope.ParamError
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeInvalidType, paramErr.Code)
+	ss.Equal("aggregation_type", paramErr.Param)
+	ss.Equal(1, paramErr.Index)
+	ss.Equal("nope", paramErr.Value)
+
+	params = url.Values{"aggregation_column": {"id|missing"}, "aggregation_type": {"count|count"}}
+	_, err = scope.GetAggregationsFromParams(context.Background(), ss.DB, &[]TestObject{}, params, nil)
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeInvalidField, paramErr.Code)
+	ss.Equal("aggregation_column", paramErr.Param)
+	ss.Equal(1, paramErr.Index)
+}
+
+func (ss *ScopesSuite) TestNewProblem() {
+	_, err := scope.ForFiltersFromParams(context.Background(), TestModel{}, url.Values{"filter_columns": {"missing"}, "filter_types": {"eq"}, "filter_values": {"1"}})
+	ss.Error(err)
+
+	problem := scope.NewProblem(errors.Wrap(err, "wrapped"))
+	ss.Equal(http.StatusBadRequest, problem.Status)
+	ss.Equal("about:blank", problem.Type)
+	ss.Equal("Bad Request", problem.Title)
+	ss.Equal("invalid filter field: missing", problem.Detail)
+	ss.Equal(scope.ParamErrorCodeInvalidField, problem.Code)
+	ss.Equal("filter_columns", problem.Param)
+	ss.Equal(0, *problem.Index)
+	ss.Equal("missing", problem.Value)
+
+	// Other errors are not exposed.
+	problem = scope.NewProblem(errors.New("pq: connection refused"))
+	ss.Equal(http.StatusInternalServerError, problem.Status)
+	ss.Empty(problem.Detail)
+	ss.Nil(problem.Index)
+}
+
+func (ss *ScopesSuite) TestWriteProblem() {
+	_, err := scope.ForSortFromParams(context.Background(), TestModel{}, url.Values{"sort_columns": {"id"}})
+	ss.Error(err)
+
+	w := httptest.NewRecorder()
+	ss.NoError(scope.WriteProblem(w, err))
+	ss.Equal(http.StatusBadRequest, w.Code)
+	ss.Equal(scope.ProblemContentType, w.Header().Get("Content-Type"))
+
+	body := map[string]interface{}{}
+	ss.NoError(json.Unmarshal(w.Body.Bytes(), &body))
+	ss.Equal(map[string]interface{}{
+		"type":   "about:blank",
+		"title":  "Bad Request",
+		"status": float64(400),
+		"detail": "missing or mismatched sort parameters",
+		"code":   "mismatched_params",
+	}, body)
+}
+
+func (ss *ScopesSuite) TestParamError_Search() {
+	s, err := scope.ForSearchFromParams(context.Background(), TestDocument{}, url.Values{"q": {"test"}})
+	ss.NoError(err)
+
+	// Errors added to the query can be found in its error.
+	var documents []TestDocument
+	q := ss.dryRunDB("mysql").Scopes(s).Find(&documents)
+
+	var paramErr *scope.ParamError
+	ss.True(errors.As(q.Error, &paramErr))
+	ss.Equal(scope.ParamErrorCodeUnsupported, paramErr.Code)
+	ss.Equal("q", paramErr.Param)
+	ss.Equal("test", paramErr.Value)
+}
@@ -0,0 +1,153 @@
+package scope_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"gorm.io/gorm"
+
+	"github.com/alphaflow/scope/gorm/scope"
+	"github.com/alphaflow/scope/util"
+)
+
+func (ss *ScopesSuite) createFacetObjects() (nulls.UUID, nulls.UUID) {
+	nuidA := util.UuidMust()
+	nuidB := util.UuidMust()
+	testObjects := []*TestObject{
+		{ID: uuid.Must(uuid.NewV4()), Nuid: nuidA, Number: 1},
+		{ID: uuid.Must(uuid.NewV4()), Nuid: nuidB, Number: 1},
+		{ID: uuid.Must(uuid.NewV4()), Nuid: nuidA, Number: 2},
+		{ID: uuid.Must(uuid.NewV4()), Number: 3},
+	}
+
+	for _, testObject := range testObjects {
+		err := ss.DB.Create(testObject).Error
+		ss.NoError(err)
+	}
+
+	return nuidA, nuidB
+}
+
+func (ss *ScopesSuite) TestGetFilterFacetsFromParams() {
+	ss.skipUnlessSupports(scope.FeatureGroupingSets)
+
+	nuidA, nuidB := ss.createFacetObjects()
+
+	params := map[string][]string{
+		"facet_columns":  {"num|null_id"},
+		"filter_columns": {"num"},
+		"filter_types":   {"EQ"},
+		"filter_values":  {"1"},
+	}
+
+	facets, err := scope.GetFilterFacetsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Len(facets, 2)
+
+	// The num facet ignores the filter on num.
+	ss.Equal(scope.FilterFacet{
+		Name: "num",
+		Options: []scope.FilterFacetOption{
+			{Value: float64(1), Count: 2},
+			{Value: float64(2), Count: 1},
+			{Value: float64(3), Count: 1},
+		},
+	}, facets[0])
+
+	// The null_id facet is restricted by the filter on num, and never includes null.
+	ss.Equal("null_id", facets[1].Name)
+	ss.ElementsMatch([]scope.FilterFacetOption{{Value: nuidA, Count: 1}, {Value: nuidB, Count: 1}}, facets[1].Options)
+
+	jsn, err := json.Marshal(facets[0])
+	ss.NoError(err)
+	ss.Equal(`{"name":"num","options":[{"value":1,"count":2},{"value":2,"count":1},{"value":3,"count":1}]}`, string(jsn))
+}
+
+func (ss *ScopesSuite) TestGetFilterFacetsFromParams_multipleFilters() {
+	ss.skipUnlessSupports(scope.FeatureGroupingSets)
+
+	nuidA, nuidB := ss.createFacetObjects()
+
+	params := map[string][]string{
+		"facet_columns":  {"num|null_id"},
+		"filter_columns": {"num|null_id"},
+		"filter_types":   {"EQ|EQ"},
+		"filter_values":  {"1|" + nuidA.UUID.String()},
+		"filter_logic":   {"AND"},
+	}
+
+	facets, err := scope.GetFilterFacetsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Len(facets, 2)
+	ss.ElementsMatch([]scope.FilterFacetOption{{Value: float64(1), Count: 1}, {Value: float64(2), Count: 1}}, facets[0].Options)
+	ss.ElementsMatch([]scope.FilterFacetOption{{Value: nuidA, Count: 1}, {Value: nuidB, Count: 1}}, facets[1].Options)
+}
+
+func (ss *ScopesSuite) TestGetFilterFacetsFromParams_orLogic() {
+	ss.skipUnlessSupports(scope.FeatureGroupingSets)
+
+	nuidA, nuidB := ss.createFacetObjects()
+
+	params := map[string][]string{
+		"facet_columns":  {"num|null_id"},
+		"filter_columns": {"num|null_id"},
+		"filter_types":   {"EQ|EQ"},
+		"filter_values":  {"1|" + nuidA.UUID.String()},
+		"filter_logic":   {"OR"},
+	}
+
+	// Each facet is restricted by the other side of the OR, rather than matching every row.
+	facets, err := scope.GetFilterFacetsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+	ss.NoError(err)
+	ss.Len(facets, 2)
+	ss.ElementsMatch([]scope.FilterFacetOption{{Value: float64(1), Count: 1}, {Value: float64(2), Count: 1}}, facets[0].Options)
+	ss.ElementsMatch([]scope.FilterFacetOption{{Value: nuidA, Count: 1}, {Value: nuidB, Count: 1}}, facets[1].Options)
+}
+
+func (ss *ScopesSuite) TestGetFilterFacets_noFilters() {
+	ss.skipUnlessSupports(scope.FeatureGroupingSets)
+
+	nuidA, nuidB := ss.createFacetObjects()
+
+	facets, err := scope.GetFilterFacets(context.Background(), ss.DB, &[]TestObject{}, []string{"null_id"}, url.Values{}, nil)
+	ss.NoError(err)
+	ss.Len(facets, 1)
+	ss.Equal(scope.FilterFacetOption{Value: nuidA, Count: 2}, facets[0].Options[0])
+	ss.Equal(scope.FilterFacetOption{Value: nuidB, Count: 1}, facets[0].Options[1])
+}
+
+func (ss *ScopesSuite) TestGetFilterFacets_withScopes() {
+	ss.skipUnlessSupports(scope.FeatureGroupingSets)
+
+	ss.createFacetObjects()
+
+	sc := scope.NewCollection(ss.DB)
+	sc.Push(func(q *gorm.DB) *gorm.DB {
+		return q.Where("num > ?", 1)
+	})
+
+	facets, err := scope.GetFilterFacets(context.Background(), ss.DB, &[]TestObject{}, []string{"num"}, url.Values{}, sc)
+	ss.NoError(err)
+	ss.Equal([]scope.FilterFacet{{
+		Name:    "num",
+		Options: []scope.FilterFacetOption{{Value: float64(2), Count: 1}, {Value: float64(3), Count: 1}},
+	}}, facets)
+}
+
+func (ss *ScopesSuite) TestGetFilterFacetsFromParams_invalid() {
+	testCases := []map[string][]string{
+		{},
+		{"facet_columns": {"not_in_db"}},
+		{"facet_columns": {"num|num"}},
+		{"facet_columns": {"num"}, "filter_columns": {"num"}, "filter_types": {"EQ|EQ"}, "filter_values": {"1"}},
+		{"facet_columns": {"num"}, "filter_columns": {"not_in_db"}, "filter_types": {"EQ"}, "filter_values": {"1"}},
+	}
+
+	for _, params := range testCases {
+		_, err := scope.GetFilterFacetsFromParams(context.Background(), ss.DB, &[]TestObject{}, url.Values(params), nil)
+		ss.Error(err)
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/gobuffalo/nulls"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/gorm"
 
 	"github.com/alphaflow/scope/gorm/scope"
 )
@@ -41,7 +40,7 @@ func (t TestModel) GetCustomSorts(ctx context.Context) scope.CustomColumns {
 
 func (ss *ScopesSuite) TestForFiltersFromParams() {
 	tm := TestModel{}
-	q := ss.DB.Session(&gorm.Session{DryRun: true}).Model(tm)
+	q := ss.dryRunDB("postgres").Model(tm)
 	q.Statement.SQL.Reset()
 	scopeQueryFunc := q.Find(&tm)
 	baseQuery := scopeQueryFunc.Statement.SQL.String()
@@ -269,6 +268,51 @@ func (ss *ScopesSuite) TestForFiltersFromParams() {
 			ExpectedQuery: fmt.Sprintf("%s WHERE (test_models.id ilike $1)", baseQuery),
 			ExpectedArgs:  []string{"%test%"},
 		},
+		{
+			Name: "Similarity Operator",
+			Params: map[string][]string{
+				"filter_columns": {"id"},
+				"filter_types":   {"sim"},
+				"filter_values":  {"test"},
+			},
+			ExpectErr:     false,
+			ExpectedQuery: fmt.Sprintf("%s WHERE ((CAST(test_models.id AS TEXT) %% $1 AND similarity(CAST(test_models.id AS TEXT), $2) >= 0.3))", baseQuery),
+			ExpectedArgs:  []string{"test", "test"},
+		},
+		{
+			Name: "Similarity Operator With Threshold",
+			Params: map[string][]string{
+				"filter_columns":              {"id"},
+				"filter_types":                {"sim"},
+				"filter_values":               {"test"},
+				"filter_similarity_threshold": {"0.5"},
+			},
+			ExpectErr:     false,
+			ExpectedQuery: fmt.Sprintf("%s WHERE ((CAST(test_models.id AS TEXT) %% $1 AND similarity(CAST(test_models.id AS TEXT), $2) >= 0.5))", baseQuery),
+			ExpectedArgs:  []string{"test", "test"},
+		},
+		{
+			Name: "Similarity Operator Below Operator Threshold",
+			Params: map[string][]string{
+				"filter_columns":              {"id"},
+				"filter_types":                {"sim"},
+				"filter_values":               {"test"},
+				"filter_similarity_threshold": {"0.1"},
+			},
+			ExpectErr:     false,
+			ExpectedQuery: fmt.Sprintf("%s WHERE (similarity(CAST(test_models.id AS TEXT), $1) >= 0.1)", baseQuery),
+			ExpectedArgs:  []string{"test"},
+		},
+		{
+			Name: "Similarity Operator Invalid Threshold",
+			Params: map[string][]string{
+				"filter_columns":              {"id"},
+				"filter_types":                {"sim"},
+				"filter_values":               {"test"},
+				"filter_similarity_threshold": {"2"},
+			},
+			ExpectErr: true,
+		},
 		{
 			Name: "In Operator",
 			Params: map[string][]string{
@@ -510,7 +554,7 @@ func (ss *ScopesSuite) TestForFiltersFromParams() {
 			}
 
 			assert.NoError(t, err)
-			q := ss.DB.Session(&gorm.Session{DryRun: true}).Model(tm)
+			q := ss.dryRunDB("postgres").Model(tm)
 			q.Statement.SQL.Reset()
 			scopeQueryFunc := q.Scopes(s).Find(&tm)
 
@@ -528,7 +572,7 @@ func (ss *ScopesSuite) TestForFiltersFromParams() {
 
 func (ss *ScopesSuite) TestForOrderFromParams() {
 	tm := TestModel{}
-	q := ss.DB.Session(&gorm.Session{DryRun: true}).Model(tm)
+	q := ss.dryRunDB("postgres").Model(tm)
 	q.Statement.SQL.Reset()
 	scopeQueryFunc := q.Find(&tm)
 	baseQuery := scopeQueryFunc.Statement.SQL.String()
@@ -608,6 +652,24 @@ func (ss *ScopesSuite) TestForOrderFromParams() {
 			ExpectErr:     false,
 			ExpectedQuery: fmt.Sprintf("%s ORDER BY test_models.id ASC,test_models.db_null_id DESC", baseQuery),
 		},
+		{
+			Name: "SIM Direction",
+			Params: map[string][]string{
+				"sort_columns":    {"id"},
+				"sort_directions": {"sim"},
+				"sort_values":     {"test"},
+			},
+			ExpectErr:     false,
+			ExpectedQuery: fmt.Sprintf("%s ORDER BY similarity(CAST(test_models.id AS TEXT), $1) DESC", baseQuery),
+		},
+		{
+			Name: "SIM Direction Missing Value",
+			Params: map[string][]string{
+				"sort_columns":    {"id"},
+				"sort_directions": {"sim"},
+			},
+			ExpectErr: true,
+		},
 		{
 			Name: "Multiple Sorts Mismatched Fields",
 			Params: map[string][]string{
@@ -635,7 +697,7 @@ func (ss *ScopesSuite) TestForOrderFromParams() {
 			}
 
 			assert.NoError(t, err)
-			q := ss.DB.Session(&gorm.Session{DryRun: true}).Model(tm)
+			q := ss.dryRunDB("postgres").Model(tm)
 			q.Statement.SQL.Reset()
 			scopeQueryFunc := q.Scopes(s).Find(&tm)
 
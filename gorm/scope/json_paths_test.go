@@ -0,0 +1,197 @@
+package scope_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+type TestJSONObject struct {
+	ID       uuid.UUID `json:"id" db:"id" gorm:"primaryKey;column:id"`
+	Metadata string    `json:"metadata" db:"metadata" filter:"path" gorm:"column:metadata"`
+}
+
+func (t TestJSONObject) TableName() string {
+	return "json_objects"
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_jsonPaths() {
+	jo := TestJSONObject{}
+	q := ss.dryRunDB("postgres").Model(jo)
+	q.Statement.SQL.Reset()
+	scopeQueryFunc := q.Find(&jo)
+	baseQuery := scopeQueryFunc.Statement.SQL.String()
+
+	testCases := []struct {
+		Name          string
+		Params        map[string][]string
+		ExpectErr     bool
+		ExpectedQuery string
+		ExpectedArgs  []string
+	}{
+		{
+			Name: "Path",
+			Params: map[string][]string{
+				"filter_columns": {"metadata.source.name"},
+				"filter_types":   {"eq"},
+				"filter_values":  {"web"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE ((json_objects.metadata #>> '{source,name}') = $1)", baseQuery),
+			ExpectedArgs:  []string{"web"},
+		},
+		{
+			Name: "Path With Cast",
+			Params: map[string][]string{
+				"filter_columns": {"metadata.count::numeric"},
+				"filter_types":   {"gt"},
+				"filter_values":  {"2"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (CAST(json_objects.metadata #>> '{count}' AS NUMERIC) > $1)", baseQuery),
+			ExpectedArgs:  []string{"2"},
+		},
+		{
+			Name: "Containment",
+			Params: map[string][]string{
+				"filter_columns": {"metadata"},
+				"filter_types":   {"ct"},
+				"filter_values":  {`{"source":{"name":"web"}}`},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (json_objects.metadata @> CAST($1 AS JSONB))", baseQuery),
+			ExpectedArgs:  []string{`{"source":{"name":"web"}}`},
+		},
+		{
+			Name: "Key Existence Path",
+			Params: map[string][]string{
+				"filter_columns": {"metadata.source"},
+				"filter_types":   {"hk"},
+				"filter_values":  {"name"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (jsonb_exists((json_objects.metadata #> '{source}'), $1))", baseQuery),
+			ExpectedArgs:  []string{"name"},
+		},
+		{
+			Name: "Invalid Path Key",
+			Params: map[string][]string{
+				"filter_columns": {"metadata.source'}'"},
+				"filter_types":   {"eq"},
+				"filter_values":  {"web"},
+			},
+			ExpectErr: true,
+		},
+		{
+			Name: "Invalid Path Cast",
+			Params: map[string][]string{
+				"filter_columns": {"metadata.count::money"},
+				"filter_types":   {"eq"},
+				"filter_values":  {"2"},
+			},
+			ExpectErr: true,
+		},
+		{
+			Name: "Cast With Containment",
+			Params: map[string][]string{
+				"filter_columns": {"metadata.source::text"},
+				"filter_types":   {"ct"},
+				"filter_values":  {`{"name":"web"}`},
+			},
+			ExpectErr: true,
+		},
+		{
+			Name: "Invalid Containment Value",
+			Params: map[string][]string{
+				"filter_columns": {"metadata"},
+				"filter_types":   {"ct"},
+				"filter_values":  {"web"},
+			},
+			ExpectErr: true,
+		},
+		{
+			Name: "Path Of Column Without Paths",
+			Params: map[string][]string{
+				"filter_columns": {"id.source"},
+				"filter_types":   {"eq"},
+				"filter_values":  {"web"},
+			},
+			ExpectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		ss.T().Run(testCase.Name, func(t *testing.T) {
+			s, err := scope.ForFiltersFromParams(context.Background(), jo, url.Values(testCase.Params))
+			if testCase.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			q := ss.dryRunDB("postgres").Model(jo)
+			q.Statement.SQL.Reset()
+			scopeQueryFunc := q.Scopes(s).Find(&jo)
+
+			args := scopeQueryFunc.Statement.Vars
+			query := scopeQueryFunc.Statement.SQL.String()
+			assert.Equal(t, testCase.ExpectedQuery, query)
+			assert.Equal(t, len(testCase.ExpectedArgs), len(args))
+
+			for i, arg := range testCase.ExpectedArgs {
+				assert.Equal(t, arg, args[i])
+			}
+		})
+	}
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_jsonPathsQuery() {
+	ss.skipUnlessSupports(scope.FeatureJSONPaths)
+
+	web := &TestJSONObject{ID: uuid.Must(uuid.NewV4()), Metadata: `{"source": {"name": "web"}, "count": 3}`}
+	err := ss.DB.Create(web).Error
+	ss.NoError(err)
+
+	mobile := &TestJSONObject{ID: uuid.Must(uuid.NewV4()), Metadata: `{"source": {"name": "mobile", "version": 2}, "count": 10}`}
+	err = ss.DB.Create(mobile).Error
+	ss.NoError(err)
+
+	testCases := []struct {
+		Column   string
+		Type     string
+		Value    string
+		Expected []uuid.UUID
+	}{
+		{Column: "metadata.source.name", Type: "EQ", Value: "web", Expected: []uuid.UUID{web.ID}},
+		// Values are compared as text unless they are cast.
+		{Column: "metadata.count::integer", Type: "GT", Value: "5", Expected: []uuid.UUID{mobile.ID}},
+		{Column: "metadata.count", Type: "GT", Value: "2", Expected: []uuid.UUID{web.ID}},
+		{Column: "metadata", Type: "CT", Value: `{"source": {"name": "mobile"}}`, Expected: []uuid.UUID{mobile.ID}},
+		{Column: "metadata.source", Type: "HK", Value: "version", Expected: []uuid.UUID{mobile.ID}},
+		{Column: "metadata.missing", Type: "NU", Expected: []uuid.UUID{web.ID, mobile.ID}},
+	}
+
+	for _, testCase := range testCases {
+		params := url.Values{
+			"filter_columns": {testCase.Column},
+			"filter_types":   {testCase.Type},
+			"filter_values":  {testCase.Value},
+		}
+
+		s, err := scope.ForFiltersFromParams(context.Background(), TestJSONObject{}, params)
+		ss.NoError(err)
+
+		objects := []TestJSONObject{}
+		err = ss.DB.Scopes(s).Find(&objects).Error
+		ss.NoError(err)
+
+		ids := make([]uuid.UUID, len(objects))
+		for i := range objects {
+			ids[i] = objects[i].ID
+		}
+
+		ss.ElementsMatch(testCase.Expected, ids, testCase.Column)
+	}
+}
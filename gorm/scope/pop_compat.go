@@ -19,7 +19,19 @@ type __stub__ struct{}
 func (s __stub__) TableName() string {
 	return "stubs"
 }
+
+// TableName returns the table of a model, a slice of models, or a pointer to either.  The table is parsed from the gorm
+// schema of the model, so TableName methods with pointer receivers and the NamingStrategy are respected.
 func TableName(entity interface{}) string {
+	return tableName(entity, NamingStrategy)
+}
+
+// tableName returns the table of a model, a slice of models, or a pointer to either, with a naming strategy.
+func tableName(entity interface{}, namer schema.Namer) string {
+	if sch, err := parseSchema(entity, namer); err == nil {
+		return sch.Table
+	}
+
 	if n, ok := entity.(Tabler); ok {
 		return n.TableName()
 	}
@@ -44,12 +56,10 @@ func TableName(entity interface{}) string {
 			return out[0].String()
 		}
 
-		strategy := schema.NamingStrategy{}
-		return strategy.TableName(el.Name())
+		return namer.TableName(el.Name())
 	}
 
-	strategy := schema.NamingStrategy{}
-	return strategy.TableName(t.Name())
+	return namer.TableName(t.Name())
 }
 
 // Paginator is a type used to represent the pagination of records
@@ -69,7 +79,7 @@ type Paginator struct {
 	TotalPages int `json:"total_pages"`
 }
 
-// PaginatorPerPageDefault is the amount of results per page
+// PaginatorPerPageDefault is the amount of results per page, which is the default PerPageDefault of NewConfig
 var PaginatorPerPageDefault = 20
 
 // PaginatorPageKey is the query parameter holding the current page index
@@ -91,7 +101,7 @@ func NewPaginator(page int, perPage int) *Paginator {
 		page = 1
 	}
 	if perPage < 1 {
-		perPage = 20
+		perPage = PaginatorPerPageDefault
 	}
 	p := &Paginator{Page: page, PerPage: perPage}
 	p.Offset = (page - 1) * p.PerPage
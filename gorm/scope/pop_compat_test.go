@@ -3,6 +3,7 @@ package scope_test
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"strings"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -27,7 +29,14 @@ type Model struct {
 // SetupTest clears database
 func (m *Model) SetupTest() {
 	m.Assertions = require.New(m.T())
-	if m.DB != nil {
+	if m.DB != nil && m.DB.Dialector.Name() != "postgres" {
+		// Other databases have no DO blocks, so each table is cleared separately.
+		tables, err := m.DB.Migrator().GetTables()
+		m.NoError(err)
+		for _, table := range tables {
+			m.NoError(m.DB.Exec(fmt.Sprintf("DELETE FROM %v", table)).Error)
+		}
+	} else if m.DB != nil {
 		err := m.DB.Exec(`
 DO
 $func$
@@ -106,9 +115,15 @@ func (m *Model) LoadFixture(name string) {
 	}
 }
 
-// NewModel creates a new model suite
+// NewModel creates a new model suite, whose database is SQLite if TEST_DATABASE_DIALECT is `sqlite`, and otherwise the
+// postgres database of database.yml.
 func NewModel() *Model {
 	m := &Model{}
+	if envy.Get("TEST_DATABASE_DIALECT", "postgres") == "sqlite" {
+		m.DB = newSQLiteDB()
+		return m
+	}
+
 	env := envy.Get("GO_ENV", "test")
 	err := pop.LoadConfigFile()
 	if err != nil {
@@ -131,3 +146,29 @@ func NewModel() *Model {
 	}
 	return m
 }
+
+// newSQLiteDB returns an in-memory SQLite database with the tables of testdata/sqlite.sql.
+func newSQLiteDB() *gorm.DB {
+	schema, err := ioutil.ReadFile("../../testdata/sqlite.sql")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The database only exists while a connection is open.
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.Exec(string(schema)).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	return db
+}
@@ -0,0 +1,256 @@
+package scope_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+type TestHouse struct {
+	ID        uuid.UUID  `json:"id" db:"id" gorm:"primaryKey;column:id"`
+	Name      string     `json:"name" db:"name" gorm:"column:name"`
+	AddressID nulls.UUID `json:"address_id" db:"address_id" gorm:"column:address_id"`
+}
+
+func (t TestHouse) TableName() string {
+	return "houses"
+}
+
+func (t TestHouse) GetRelations(ctx context.Context) scope.Relations {
+	return scope.Relations{{Name: "rooms", ModelPtr: &TestRoom{}, JoinClause: "rooms.house_id = houses.id"}}
+}
+
+type TestRoom struct {
+	ID      uuid.UUID     `json:"id" db:"id" gorm:"primaryKey;column:id"`
+	HouseID uuid.UUID     `json:"house_id" db:"house_id" gorm:"column:house_id"`
+	Name    string        `json:"name" db:"name" gorm:"column:name"`
+	Area    nulls.Float64 `json:"area" db:"area" gorm:"column:area"`
+}
+
+func (t TestRoom) TableName() string {
+	return "rooms"
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_relations() {
+	th := TestHouse{}
+	q := ss.dryRunDB("postgres").Model(th)
+	q.Statement.SQL.Reset()
+	scopeQueryFunc := q.Find(&th)
+	baseQuery := scopeQueryFunc.Statement.SQL.String()
+
+	testCases := []struct {
+		Name          string
+		Params        map[string][]string
+		ExpectErr     bool
+		ExpectedQuery string
+		ExpectedArgs  []string
+	}{
+		{
+			Name: "Relation Column",
+			Params: map[string][]string{
+				"filter_columns": {"rooms.area"},
+				"filter_types":   {"gt"},
+				"filter_values":  {"20"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (EXISTS (SELECT 1 FROM rooms WHERE (rooms.house_id = houses.id) AND rooms.area > $1))", baseQuery),
+			ExpectedArgs:  []string{"20"},
+		},
+		{
+			Name: "Relation Column None",
+			Params: map[string][]string{
+				"filter_columns":     {"name|rooms.name"},
+				"filter_types":       {"eq|in"},
+				"filter_values":      {"test|kitchen,den"},
+				"filter_logic":       {"and"},
+				"filter_quantifiers": {"|none"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (houses.name = $1 AND NOT EXISTS (SELECT 1 FROM rooms WHERE (rooms.house_id = houses.id) AND rooms.name in ($2,  $3)))", baseQuery),
+			ExpectedArgs:  []string{"test", "kitchen", "den"},
+		},
+		{
+			Name: "Relation Column All",
+			Params: map[string][]string{
+				"filter_columns":      {"rooms.area"},
+				"filter_types":        {"gt"},
+				"filter_values":       {"20"},
+				"filter_quantifiers":  {"all"},
+				"filter_left_parens":  {"0"},
+				"filter_right_parens": {"0"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE ((NOT EXISTS (SELECT 1 FROM rooms WHERE (rooms.house_id = houses.id) AND (rooms.area > $1) IS NOT TRUE)))", baseQuery),
+			ExpectedArgs:  []string{"20"},
+		},
+		{
+			Name: "Relation Count",
+			Params: map[string][]string{
+				"filter_columns": {"rooms"},
+				"filter_types":   {"eq"},
+				"filter_values":  {"0"},
+			},
+			ExpectedQuery: fmt.Sprintf("%s WHERE (CAST((SELECT COUNT(*) FROM rooms WHERE rooms.house_id = houses.id) AS BIGINT) = $1)", baseQuery),
+			ExpectedArgs:  []string{"0"},
+		},
+		{
+			Name: "Invalid Relation Column",
+			Params: map[string][]string{
+				"filter_columns": {"rooms.not_in_db"},
+				"filter_types":   {"eq"},
+				"filter_values":  {"0"},
+			},
+			ExpectErr: true,
+		},
+		{
+			Name: "Invalid Quantifier",
+			Params: map[string][]string{
+				"filter_columns":     {"rooms.area"},
+				"filter_types":       {"eq"},
+				"filter_values":      {"0"},
+				"filter_quantifiers": {"some"},
+			},
+			ExpectErr: true,
+		},
+		{
+			Name: "Quantifier Without Relation",
+			Params: map[string][]string{
+				"filter_columns":     {"name"},
+				"filter_types":       {"eq"},
+				"filter_values":      {"test"},
+				"filter_quantifiers": {"any"},
+			},
+			ExpectErr: true,
+		},
+		{
+			Name: "Mismatched Quantifiers",
+			Params: map[string][]string{
+				"filter_columns":     {"name|rooms.area"},
+				"filter_types":       {"eq|eq"},
+				"filter_values":      {"test|0"},
+				"filter_logic":       {"and"},
+				"filter_quantifiers": {"any"},
+			},
+			ExpectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		ss.T().Run(testCase.Name, func(t *testing.T) {
+			s, err := scope.ForFiltersFromParams(context.Background(), th, url.Values(testCase.Params))
+			if testCase.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			q := ss.dryRunDB("postgres").Model(th)
+			q.Statement.SQL.Reset()
+			scopeQueryFunc := q.Scopes(s).Find(&th)
+
+			args := scopeQueryFunc.Statement.Vars
+			query := scopeQueryFunc.Statement.SQL.String()
+			assert.Equal(t, testCase.ExpectedQuery, query)
+			assert.Equal(t, len(testCase.ExpectedArgs), len(args))
+
+			for i, arg := range testCase.ExpectedArgs {
+				assert.Equal(t, arg, args[i])
+			}
+		})
+	}
+}
+
+// TestNamedHouse is related to the rooms of its house, and to the rooms named after it.
+type TestNamedHouse struct {
+	TestHouse
+}
+
+func (t TestNamedHouse) GetRelations(ctx context.Context) scope.Relations {
+	return scope.Relations{{Name: "rooms", ModelPtr: &TestRoom{}, JoinClause: "rooms.house_id = houses.id OR rooms.name = houses.name"}}
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_relationsJoinClause() {
+	th := TestNamedHouse{}
+	q := ss.dryRunDB("postgres").Model(th)
+	q.Statement.SQL.Reset()
+	baseQuery := q.Find(&th).Statement.SQL.String()
+
+	params := url.Values{"filter_columns": {"rooms.area"}, "filter_types": {"gt"}, "filter_values": {"20"}}
+	s, err := scope.ForFiltersFromParams(context.Background(), th, params)
+	ss.NoError(err)
+
+	// The join clause is grouped, so that the filter clause applies to all of the related rows.
+	q = ss.dryRunDB("postgres").Model(th)
+	q.Statement.SQL.Reset()
+	query := q.Scopes(s).Find(&th).Statement.SQL.String()
+	ss.Equal(fmt.Sprintf("%s WHERE (EXISTS (SELECT 1 FROM rooms WHERE (rooms.house_id = houses.id OR rooms.name = houses.name) AND rooms.area > $1))", baseQuery), query)
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_relationsQuery() {
+	large := &TestHouse{ID: uuid.Must(uuid.NewV4()), Name: "large"}
+	err := ss.DB.Create(large).Error
+	ss.NoError(err)
+
+	small := &TestHouse{ID: uuid.Must(uuid.NewV4()), Name: "small"}
+	err = ss.DB.Create(small).Error
+	ss.NoError(err)
+
+	empty := &TestHouse{ID: uuid.Must(uuid.NewV4()), Name: "empty"}
+	err = ss.DB.Create(empty).Error
+	ss.NoError(err)
+
+	rooms := []TestRoom{
+		{ID: uuid.Must(uuid.NewV4()), HouseID: large.ID, Name: "kitchen", Area: nulls.NewFloat64(30)},
+		{ID: uuid.Must(uuid.NewV4()), HouseID: large.ID, Name: "den", Area: nulls.NewFloat64(25)},
+		{ID: uuid.Must(uuid.NewV4()), HouseID: small.ID, Name: "kitchen", Area: nulls.NewFloat64(10)},
+		{ID: uuid.Must(uuid.NewV4()), HouseID: small.ID, Name: "closet"},
+	}
+
+	for i := range rooms {
+		err = ss.DB.Create(&rooms[i]).Error
+		ss.NoError(err)
+	}
+
+	testCases := []struct {
+		Name       string
+		Column     string
+		Type       string
+		Value      string
+		Quantifier string
+		Expected   []uuid.UUID
+	}{
+		{Name: "any", Column: "rooms.area", Type: "GT", Value: "20", Expected: []uuid.UUID{large.ID}},
+		{Name: "none", Column: "rooms.area", Type: "GT", Value: "20", Quantifier: "NONE", Expected: []uuid.UUID{small.ID, empty.ID}},
+		{Name: "all", Column: "rooms.area", Type: "GT", Value: "20", Quantifier: "ALL", Expected: []uuid.UUID{large.ID, empty.ID}},
+		{Name: "all with nulls", Column: "rooms.area", Type: "LT", Value: "20", Quantifier: "ALL", Expected: []uuid.UUID{empty.ID}},
+		{Name: "count", Column: "rooms", Type: "EQ", Value: "0", Expected: []uuid.UUID{empty.ID}},
+		{Name: "count greater than", Column: "rooms", Type: "GTE", Value: "2", Expected: []uuid.UUID{large.ID, small.ID}},
+	}
+
+	for _, testCase := range testCases {
+		params := url.Values{
+			"filter_columns":     {testCase.Column},
+			"filter_types":       {testCase.Type},
+			"filter_values":      {testCase.Value},
+			"filter_quantifiers": {testCase.Quantifier},
+		}
+
+		s, err := scope.ForFiltersFromParams(context.Background(), TestHouse{}, params)
+		ss.NoError(err)
+
+		houses := []TestHouse{}
+		err = ss.DB.Scopes(s).Find(&houses).Error
+		ss.NoError(err)
+
+		ids := make([]uuid.UUID, len(houses))
+		for i := range houses {
+			ids[i] = houses[i].ID
+		}
+
+		ss.ElementsMatch(testCase.Expected, ids, testCase.Name)
+	}
+}
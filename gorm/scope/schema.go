@@ -0,0 +1,123 @@
+package scope
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/alphaflow/scope/util"
+)
+
+// NamingStrategy is the default naming strategy of the table and column names of models.  Scopes use the
+// NamingStrategy of the config of their context, or the NamingStrategy of the gorm.DB that they are applied to, so this
+// is only used by functions that are passed neither, ex. TableName, and by configs without a NamingStrategy.
+var NamingStrategy schema.Namer = schema.NamingStrategy{}
+
+// schemaCaches holds the cache of the parsed schemas of each naming strategy, since gorm caches schemas by their type.
+var schemaCaches sync.Map
+
+// parseSchema parses the gorm schema of a model with a naming strategy.
+func parseSchema(model interface{}, namer schema.Namer) (*schema.Schema, error) {
+	return schema.Parse(model, schemaCache(namer), namer)
+}
+
+// schemaCache returns the cache of the parsed schemas of a naming strategy.  Naming strategies that are not comparable
+// can't be keyed, so their schemas are not cached.
+func schemaCache(namer schema.Namer) *sync.Map {
+	key, ok := namerCacheKey(namer)
+	if !ok {
+		return &sync.Map{}
+	}
+
+	if cache, ok := schemaCaches.Load(key); ok {
+		return cache.(*sync.Map)
+	}
+
+	cache, _ := schemaCaches.LoadOrStore(key, &sync.Map{})
+	return cache.(*sync.Map)
+}
+
+// namerCacheKey returns a naming strategy as a key of a map, or false if it is not comparable.
+func namerCacheKey(namer schema.Namer) (interface{}, bool) {
+	if namer == nil || !reflect.TypeOf(namer).Comparable() {
+		return nil, false
+	}
+
+	return namer, true
+}
+
+// namingStrategyFromContext returns the naming strategy of the config of a context, see Config.NamingStrategy.
+func namingStrategyFromContext(ctx context.Context) schema.Namer {
+	if namer := ConfigFromContext(ctx).NamingStrategy; namer != nil {
+		return namer
+	}
+
+	return NamingStrategy
+}
+
+// namingStrategyFromDB returns the naming strategy of a gorm.DB.
+func namingStrategyFromDB(db *gorm.DB) schema.Namer {
+	if db == nil || db.Config == nil || db.NamingStrategy == nil {
+		return NamingStrategy
+	}
+
+	return db.NamingStrategy
+}
+
+// modelFields returns the fields of a model, with the DBName of each field derived from the gorm schema of the model,
+// ex. a `gorm:"column:..."` tag, an `embeddedPrefix`, or the naming strategy.  A `db` tag is only used if the field is
+// not a column of the schema, or if the model has no valid schema, and a `db:"-"` tag hides the field either way.
+func modelFields(model interface{}, namer schema.Namer) ([]util.ModelField, error) {
+	fields, err := util.ModelFields(model)
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := parseSchema(model, namer)
+	if err != nil {
+		return fields, nil
+	}
+
+	// Fields of the schema are keyed by their path, since fields of embedded structs may have the same names.
+	schemaFields := make(map[string]*schema.Field, len(sch.Fields))
+	for _, schemaField := range sch.Fields {
+		if schemaField.DBName == "" || schemaField.DataType == "" || !schemaField.Readable {
+			continue
+		}
+
+		schemaFields[strings.Join(schemaField.BindNames, ".")] = schemaField
+	}
+
+	_, t := util.StructValueAndType(model)
+	for i, field := range fields {
+		if field.Tag.Get("db") == "-" {
+			continue
+		}
+
+		if schemaField, ok := schemaFields[fieldPath(t, field.Index)]; ok {
+			fields[i].DBName = schemaField.DBName
+		}
+	}
+
+	return fields, nil
+}
+
+// fieldPath returns the names of the fields at an index sequence of the struct type t, ex. `Base.ID`.
+func fieldPath(t reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, fieldIndex := range index {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		sf := t.Field(fieldIndex)
+		names[i] = sf.Name
+		t = sf.Type
+	}
+
+	return strings.Join(names, ".")
+}
@@ -0,0 +1,122 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"gorm.io/gorm/schema"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+type TestGormDatedObject struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey"`
+	Number     float64   `json:"num" gorm:"column:num"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Ignored    string    `json:"ignored" gorm:"-"`
+}
+
+func (t *TestGormDatedObject) TableName() string {
+	return "dated_objects"
+}
+
+type TestGormWidget struct {
+	ID       uuid.UUID `json:"id" gorm:"primaryKey"`
+	WidgetID string    `json:"widget_id" db:"legacy_id" gorm:"column:widget_identifier"`
+	Legacy   string    `json:"legacy" db:"legacy"`
+	Hidden   string    `json:"hidden" db:"-"`
+}
+
+func (ss *ScopesSuite) TestTableName_Schema() {
+	ss.Equal("dated_objects", scope.TableName(&TestGormDatedObject{}))
+	ss.Equal("dated_objects", scope.TableName(&[]TestGormDatedObject{}))
+	ss.Equal("test_gorm_widgets", scope.TableName(&TestGormWidget{}))
+
+	defer func(namingStrategy schema.Namer) {
+		scope.NamingStrategy = namingStrategy
+	}(scope.NamingStrategy)
+
+	scope.NamingStrategy = schema.NamingStrategy{TablePrefix: "app_", SingularTable: true}
+	ss.Equal("app_test_gorm_widget", scope.TableName(&TestGormWidget{}))
+	ss.Equal("dated_objects", scope.TableName(&TestGormDatedObject{}))
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_Schema() {
+	filters, err := scope.GetAllFilterColumns(context.Background(), &TestGormDatedObject{})
+	ss.NoError(err)
+
+	filtersMap := make(map[string]scope.CustomColumn, len(filters))
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+
+	ss.Len(filtersMap, 3)
+	ss.Equal("dated_objects.id", filtersMap["id"].Statement)
+	ss.Equal("dated_objects.num", filtersMap["num"].Statement)
+	ss.Equal("dated_objects.occurred_at", filtersMap["occurred_at"].Statement)
+	ss.Equal(reflect.TypeOf(time.Time{}), filtersMap["occurred_at"].ResultType)
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_SchemaWithDBTags() {
+	filters, err := scope.GetAllFilterColumns(context.Background(), &TestGormWidget{})
+	ss.NoError(err)
+
+	filtersMap := make(map[string]scope.CustomColumn, len(filters))
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+
+	ss.Len(filtersMap, 3)
+	ss.Equal("test_gorm_widgets.id", filtersMap["id"].Statement)
+	ss.Equal("test_gorm_widgets.widget_identifier", filtersMap["widget_id"].Statement)
+	ss.Equal("test_gorm_widgets.legacy", filtersMap["legacy"].Statement)
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_SchemaNamingStrategy() {
+	config := scope.NewConfig()
+	config.NamingStrategy = schema.NamingStrategy{TablePrefix: "app_"}
+	ctx := scope.WithConfig(context.Background(), config)
+
+	filters, err := scope.GetAllFilterColumns(ctx, &TestGormWidget{})
+	ss.NoError(err)
+	ss.Equal("app_test_gorm_widgets.id", filters[0].Statement)
+
+	// The columns of each naming strategy are cached separately.
+	filters, err = scope.GetAllFilterColumns(context.Background(), &TestGormWidget{})
+	ss.NoError(err)
+	ss.Equal("test_gorm_widgets.id", filters[0].Statement)
+}
+
+func (ss *ScopesSuite) TestForUuidIDForModel_SchemaNamingStrategy() {
+	db := ss.dryRunDB("postgres")
+	db.NamingStrategy = schema.NamingStrategy{TablePrefix: "app_"}
+
+	models := []TestGormWidget{}
+	q := db.Scopes(scope.ForUuidIDForModel(uuid.Nil, &TestGormWidget{})).Find(&models)
+	ss.Contains(q.Statement.SQL.String(), "app_test_gorm_widgets.id = $1")
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_Schema() {
+	ss.createDatedObjects(map[string][]float64{
+		"2021-01-15": {1, 2},
+		"2021-02-15": {3},
+	})
+
+	params := map[string][]string{
+		"filter_columns": {"occurred_at"},
+		"filter_types":   {"gt"},
+		"filter_values":  {"2021-02-01"},
+	}
+
+	s, err := scope.ForFiltersFromParams(context.Background(), TestGormDatedObject{}, url.Values(params))
+	ss.NoError(err)
+
+	var objects []TestGormDatedObject
+	err = ss.DB.Scopes(s).Find(&objects).Error
+	ss.NoError(err)
+	ss.Len(objects, 1)
+	ss.Equal(float64(3), objects[0].Number)
+}
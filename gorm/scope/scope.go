@@ -6,6 +6,7 @@ import (
 	"github.com/gobuffalo/nulls"
 
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
@@ -63,35 +64,79 @@ func ForLimit(limit int) ScopeFunc {
 	}
 }
 
-func ForID(id string) ScopeFunc {
-	return func(q *gorm.DB) *gorm.DB {
-		if len(id) > 0 {
-			return q.Where("id = ?", uuid.Must(uuid.FromString(id)))
-		}
-
-		return q.Where("1 = 0")
+// ParseID parses an ID, ex. from a URL.  Scopes of string IDs such as ForID don't return errors, so IDs from requests
+// should be parsed first to reject invalid IDs, and then scoped by their UUID, ex. with ForUuidID, or be scoped with
+// ForValidID, which returns an error for an invalid ID.
+func ParseID(id string) (uuid.UUID, error) {
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		return uuid.Nil, errors.Wrapf(err, "invalid id: %v", id)
 	}
+
+	return uid, nil
 }
 
-func ForIDWithTableName(id string, tablename string) ScopeFunc {
-	return func(q *gorm.DB) *gorm.DB {
-		if len(id) > 0 {
-			return q.Where(fmt.Sprintf("%s.id = ?", tablename), uuid.Must(uuid.FromString(id)))
+// forParsedID returns the scope of a parsed ID.  An empty ID matches no rows, as an empty IDSet does.  No row has an
+// invalid ID, so it matches no rows, or all rows if `not` is set, since scopes cannot return errors.  See forValidID to
+// reject invalid IDs, ex. before an update or a delete.
+func forParsedID(id string, not bool, scopeForUuidID func(uid uuid.UUID) ScopeFunc) ScopeFunc {
+	uid, err := ParseID(id)
+	if err != nil {
+		return func(q *gorm.DB) *gorm.DB {
+			if not && len(id) > 0 {
+				return q
+			}
+
+			return q.Where("1 = 0")
 		}
+	}
 
-		return q.Where("1 = 0")
+	return scopeForUuidID(uid)
+}
+
+// forValidID returns the scope of a parsed ID, or a ParamError if the ID is empty or invalid.
+func forValidID(id string, scopeForUuidID func(uid uuid.UUID) ScopeFunc) (ScopeFunc, error) {
+	uid, err := ParseID(id)
+	if err != nil {
+		return nil, newParamError(ParamErrorCodeInvalidValue, "id", id, "invalid id: %v", id)
 	}
+
+	return scopeForUuidID(uid), nil
+}
+
+// ForID scopes a query to the row with an ID.  An empty or invalid ID matches no rows, see ForValidID.
+func ForID(id string) ScopeFunc {
+	return forParsedID(id, false, ForUuidID)
+}
+
+func ForIDWithTableName(id string, tablename string) ScopeFunc {
+	return forParsedID(id, false, func(uid uuid.UUID) ScopeFunc {
+		return ForUuidIDWithTableName(uid, tablename)
+	})
 }
 
 func ForIDForModel(id string, model interface{}) ScopeFunc {
-	return func(q *gorm.DB) *gorm.DB {
-		if len(id) > 0 {
-			tableName := TableName(model)
-			return q.Where(fmt.Sprintf("%s.id = ?", tableName), uuid.Must(uuid.FromString(id)))
-		}
+	return forParsedID(id, false, func(uid uuid.UUID) ScopeFunc {
+		return ForUuidIDForModel(uid, model)
+	})
+}
 
-		return q.Where("1 = 0")
-	}
+// ForValidID scopes a query to the row with an ID, or returns a ParamError if the ID is empty or invalid, ex. an ID
+// from a URL that should be rejected with a 400.
+func ForValidID(id string) (ScopeFunc, error) {
+	return forValidID(id, ForUuidID)
+}
+
+func ForValidIDWithTableName(id string, tablename string) (ScopeFunc, error) {
+	return forValidID(id, func(uid uuid.UUID) ScopeFunc {
+		return ForUuidIDWithTableName(uid, tablename)
+	})
+}
+
+func ForValidIDForModel(id string, model interface{}) (ScopeFunc, error) {
+	return forValidID(id, func(uid uuid.UUID) ScopeFunc {
+		return ForUuidIDForModel(uid, model)
+	})
 }
 
 func ForIDs(ids []uuid.UUID) ScopeFunc {
@@ -115,7 +160,7 @@ func ForIDsWithTableName(ids []uuid.UUID, tablename string) ScopeFunc {
 func ForIDSetWithTableName(idSet IDSet, tablename string) ScopeFunc {
 	return func(q *gorm.DB) *gorm.DB {
 		if len(idSet) > 0 {
-			return q.Where(fmt.Sprintf("%s.id in (?)", tablename), idSet.Keys())
+			return q.Where(fmt.Sprintf("%s in (?)", quoteColumn(getDialect(q), tablename, "id")), idSet.Keys())
 		}
 
 		return q.Where("1 = 0")
@@ -129,43 +174,47 @@ func ForIDsForModel(ids []uuid.UUID, model interface{}) ScopeFunc {
 func ForIDSetForModel(idSet IDSet, model interface{}) ScopeFunc {
 	return func(q *gorm.DB) *gorm.DB {
 		if len(idSet) > 0 {
-			tableName := TableName(model)
-			return q.Where(fmt.Sprintf("%s.id in (?)", tableName), idSet.Keys())
+			tableName := tableName(model, namingStrategyFromDB(q))
+			return q.Where(fmt.Sprintf("%s in (?)", quoteColumn(getDialect(q), tableName, "id")), idSet.Keys())
 		}
 
 		return q.Where("1 = 0")
 	}
 }
 
+// ForNotID scopes a query to the rows without an ID.  An empty ID matches no rows, and an invalid ID matches all rows,
+// see ForNotValidID.
 func ForNotID(id string) ScopeFunc {
-	return func(q *gorm.DB) *gorm.DB {
-		if len(id) > 0 {
-			return q.Where("id != ?", uuid.Must(uuid.FromString(id)))
-		}
-
-		return q.Where("1 = 0")
-	}
+	return forParsedID(id, true, ForNotUuidID)
 }
 
 func ForNotIDWithTableName(id string, tablename string) ScopeFunc {
-	return func(q *gorm.DB) *gorm.DB {
-		if len(id) > 0 {
-			return q.Where(fmt.Sprintf("%s.id != ?", tablename), uuid.Must(uuid.FromString(id)))
-		}
-
-		return q.Where("1 = 0")
-	}
+	return forParsedID(id, true, func(uid uuid.UUID) ScopeFunc {
+		return ForNotUuidIDWithTableName(uid, tablename)
+	})
 }
 
 func ForNotIDForNotModel(id string, model interface{}) ScopeFunc {
-	return func(q *gorm.DB) *gorm.DB {
-		if len(id) > 0 {
-			tableName := TableName(model)
-			return q.Where(fmt.Sprintf("%s.id != ?", tableName), uuid.Must(uuid.FromString(id)))
-		}
+	return forParsedID(id, true, func(uid uuid.UUID) ScopeFunc {
+		return ForNotUuidIDForModel(uid, model)
+	})
+}
 
-		return q.Where("1 = 0")
-	}
+// ForNotValidID scopes a query to the rows without an ID, or returns a ParamError if the ID is empty or invalid.
+func ForNotValidID(id string) (ScopeFunc, error) {
+	return forValidID(id, ForNotUuidID)
+}
+
+func ForNotValidIDWithTableName(id string, tablename string) (ScopeFunc, error) {
+	return forValidID(id, func(uid uuid.UUID) ScopeFunc {
+		return ForNotUuidIDWithTableName(uid, tablename)
+	})
+}
+
+func ForNotValidIDForModel(id string, model interface{}) (ScopeFunc, error) {
+	return forValidID(id, func(uid uuid.UUID) ScopeFunc {
+		return ForNotUuidIDForModel(uid, model)
+	})
 }
 
 func ForNotIDs(ids []uuid.UUID) ScopeFunc {
@@ -189,7 +238,7 @@ func ForNotIDsWithTableName(ids []uuid.UUID, tablename string) ScopeFunc {
 func ForNotIDSetWithTableName(idSet IDSet, tablename string) ScopeFunc {
 	return func(q *gorm.DB) *gorm.DB {
 		if len(idSet) > 0 {
-			return q.Where(fmt.Sprintf("%s.id not in (?)", tablename), idSet.Keys())
+			return q.Where(fmt.Sprintf("%s not in (?)", quoteColumn(getDialect(q), tablename, "id")), idSet.Keys())
 		}
 
 		return q.Where("1 = 0")
@@ -203,8 +252,8 @@ func ForNotIDsForModel(ids []uuid.UUID, model interface{}) ScopeFunc {
 func ForNotIDSetForModel(idSet IDSet, model interface{}) ScopeFunc {
 	return func(q *gorm.DB) *gorm.DB {
 		if len(idSet) > 0 {
-			tableName := TableName(model)
-			return q.Where(fmt.Sprintf("%s.id not in (?)", tableName), idSet.Keys())
+			tableName := tableName(model, namingStrategyFromDB(q))
+			return q.Where(fmt.Sprintf("%s not in (?)", quoteColumn(getDialect(q), tableName, "id")), idSet.Keys())
 		}
 
 		return q.Where("1 = 0")
@@ -223,6 +272,30 @@ func ForNotUuidID(uid uuid.UUID) ScopeFunc {
 	}
 }
 
+func ForUuidIDWithTableName(uid uuid.UUID, tablename string) ScopeFunc {
+	return func(q *gorm.DB) *gorm.DB {
+		return q.Where(fmt.Sprintf("%s = ?", quoteColumn(getDialect(q), tablename, "id")), uid)
+	}
+}
+
+func ForUuidIDForModel(uid uuid.UUID, model interface{}) ScopeFunc {
+	return func(q *gorm.DB) *gorm.DB {
+		return ForUuidIDWithTableName(uid, tableName(model, namingStrategyFromDB(q)))(q)
+	}
+}
+
+func ForNotUuidIDWithTableName(uid uuid.UUID, tablename string) ScopeFunc {
+	return func(q *gorm.DB) *gorm.DB {
+		return q.Where(fmt.Sprintf("%s != ?", quoteColumn(getDialect(q), tablename, "id")), uid)
+	}
+}
+
+func ForNotUuidIDForModel(uid uuid.UUID, model interface{}) ScopeFunc {
+	return func(q *gorm.DB) *gorm.DB {
+		return ForNotUuidIDWithTableName(uid, tableName(model, namingStrategyFromDB(q)))(q)
+	}
+}
+
 func ForNullsUuidID(uid nulls.UUID) ScopeFunc {
 	return func(q *gorm.DB) *gorm.DB {
 		if uid.Valid {
@@ -241,7 +314,7 @@ func ForNullDeletedAt() ScopeFunc {
 
 func ForNullDeletedAtForModel(model interface{}) ScopeFunc {
 	return func(q *gorm.DB) *gorm.DB {
-		tableName := TableName(model)
+		tableName := tableName(model, namingStrategyFromDB(q))
 		return q.Where(fmt.Sprintf("%s.deleted_at is null", tableName))
 	}
 }
@@ -254,7 +327,7 @@ func ForNotNullDeletedAt() ScopeFunc {
 
 func ForNotNullDeletedAtForModel(model interface{}) ScopeFunc {
 	return func(q *gorm.DB) *gorm.DB {
-		tableName := TableName(model)
+		tableName := tableName(model, namingStrategyFromDB(q))
 		return q.Where(fmt.Sprintf("%s.deleted_at is not null", tableName))
 	}
 }
@@ -273,44 +346,13 @@ func ForNotNull(field string) ScopeFunc {
 	}
 }
 
+// PaginateFromParams paginates a query based on the `page` and `per_page` params, with the pagination of the default config, see
+// ConfigFromContext.
 func PaginateFromParams(params PaginationParams) ScopeFunc {
-	return func(q *gorm.DB) *gorm.DB {
-		paginator := NewPaginatorFromParams(params)
-		page := paginator.Page
-		if page == 0 {
-			page = 1
-		}
-
-		pageSize := paginator.PerPage
-		switch {
-		case pageSize > 100:
-			pageSize = 100
-		case pageSize <= 0:
-			pageSize = 10
-		}
-
-		offset := (page - 1) * pageSize
-		return q.Offset(offset).Limit(pageSize)
-	}
+	return ConfigFromContext(nil).ForPaginateFromParams(params)
 }
 
+// Paginate paginates a query, with the pagination of the default config, see ConfigFromContext.
 func Paginate(page int, perPage int) ScopeFunc {
-	return func(q *gorm.DB) *gorm.DB {
-		paginator := NewPaginator(page, perPage)
-		page := paginator.Page
-		if page == 0 {
-			page = 1
-		}
-
-		pageSize := paginator.PerPage
-		switch {
-		case pageSize > 100:
-			pageSize = 100
-		case pageSize <= 0:
-			pageSize = 10
-		}
-
-		offset := (page - 1) * pageSize
-		return q.Offset(offset).Limit(pageSize)
-	}
+	return ConfigFromContext(nil).Paginate(page, perPage)
 }
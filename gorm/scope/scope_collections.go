@@ -13,6 +13,7 @@ import (
 type Collection struct {
 	tx     *gorm.DB
 	scopes []ScopeFunc
+	config *Config
 }
 
 func NewCollection(tx ...*gorm.DB) *Collection {
@@ -26,6 +27,14 @@ func NewCollection(tx ...*gorm.DB) *Collection {
 	return sc
 }
 
+// WithConfig attaches a config to the collection, which the functions passed the collection use unless their context
+// has a config, see ConfigFromContext.
+func (sc *Collection) WithConfig(config *Config) *Collection {
+	sc.config = config
+
+	return sc
+}
+
 func (sc *Collection) Get() []ScopeFunc {
 	return sc.scopes
 }
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/gobuffalo/suite/v3"
+
+	"github.com/alphaflow/scope/gorm/scope"
 )
 
 type ScopesSuite struct {
@@ -19,3 +21,21 @@ func Test_ScopesSuite(t *testing.T) {
 
 	suite.Run(t, ss)
 }
+
+// dialect returns the dialect of the database of the suite.
+func (ss *ScopesSuite) dialect() scope.Dialect {
+	if ss.DB.Dialector.Name() == "sqlite" {
+		return scope.SQLiteDialect
+	}
+
+	return scope.PostgresDialect
+}
+
+// skipUnlessSupports skips a test unless the database of the suite supports all of the features, ex. a filter type.
+func (ss *ScopesSuite) skipUnlessSupports(features ...string) {
+	for _, feature := range features {
+		if !ss.dialect().Supports(feature) {
+			ss.T().Skipf("%v is not supported by %v", feature, ss.dialect().Name())
+		}
+	}
+}
@@ -2,8 +2,9 @@ package scope_test
 
 import (
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 
-	"github.com/alphaflow/scope"
+	"github.com/alphaflow/scope/gorm/scope"
 )
 
 func (ss *ScopesSuite) TestNewIdSet() {
@@ -39,3 +40,82 @@ func (ss *ScopesSuite) TestNewIdSet_Keys() {
 
 	ss.ElementsMatch([]uuid.UUID{a, b, c}, keys)
 }
+
+func (ss *ScopesSuite) TestParseID() {
+	id := uuid.Must(uuid.NewV4())
+
+	parsed, err := scope.ParseID(id.String())
+	ss.NoError(err)
+	ss.Equal(id, parsed)
+
+	_, err = scope.ParseID("not-an-id")
+	ss.Error(err)
+
+	_, err = scope.ParseID("")
+	ss.Error(err)
+}
+
+func (ss *ScopesSuite) TestForID_Invalid() {
+	for _, s := range []scope.ScopeFunc{
+		scope.ForID("not-an-id"),
+		scope.ForIDWithTableName("not-an-id", "objects"),
+		scope.ForIDForModel("not-an-id", TestObject{}),
+		scope.ForID(""),
+		scope.ForIDWithTableName("", "objects"),
+		scope.ForIDForModel("", TestObject{}),
+		scope.ForNotID(""),
+		scope.ForNotIDWithTableName("", "objects"),
+		scope.ForNotIDForNotModel("", TestObject{}),
+	} {
+		var objects []TestObject
+		q := ss.dryRunDB("postgres").Scopes(s).Find(&objects)
+		ss.NoError(q.Error)
+		ss.Contains(q.Statement.SQL.String(), "WHERE 1 = 0")
+	}
+
+	// No row has an invalid ID, so all rows match.
+	for _, s := range []scope.ScopeFunc{
+		scope.ForNotID("not-an-id"),
+		scope.ForNotIDWithTableName("not-an-id", "objects"),
+		scope.ForNotIDForNotModel("not-an-id", TestObject{}),
+	} {
+		var objects []TestObject
+		q := ss.dryRunDB("postgres").Scopes(s).Find(&objects)
+		ss.NoError(q.Error)
+		ss.NotContains(q.Statement.SQL.String(), "WHERE")
+	}
+
+	var objects []TestObject
+	id := uuid.Must(uuid.NewV4())
+	q := ss.dryRunDB("postgres").Scopes(scope.ForIDForModel(id.String(), TestObject{})).Find(&objects)
+	ss.NoError(q.Error)
+	ss.Contains(q.Statement.SQL.String(), "WHERE objects.id = $1")
+}
+
+func (ss *ScopesSuite) TestForValidID() {
+	for _, newScope := range []func(id string) (scope.ScopeFunc, error){
+		scope.ForValidID,
+		scope.ForNotValidID,
+		func(id string) (scope.ScopeFunc, error) { return scope.ForValidIDWithTableName(id, "objects") },
+		func(id string) (scope.ScopeFunc, error) { return scope.ForNotValidIDWithTableName(id, "objects") },
+		func(id string) (scope.ScopeFunc, error) { return scope.ForValidIDForModel(id, TestObject{}) },
+		func(id string) (scope.ScopeFunc, error) { return scope.ForNotValidIDForModel(id, TestObject{}) },
+	} {
+		for _, id := range []string{"", "not-an-id"} {
+			_, err := newScope(id)
+			var paramErr *scope.ParamError
+			ss.True(errors.As(err, &paramErr))
+			ss.Equal(scope.ParamErrorCodeInvalidValue, paramErr.Code)
+			ss.Equal(id, paramErr.Value)
+		}
+	}
+
+	id := uuid.Must(uuid.NewV4())
+	s, err := scope.ForNotValidIDForModel(id.String(), TestObject{})
+	ss.NoError(err)
+
+	var objects []TestObject
+	q := ss.dryRunDB("postgres").Scopes(s).Find(&objects)
+	ss.NoError(q.Error)
+	ss.Contains(q.Statement.SQL.String(), "WHERE objects.id != $1")
+}
@@ -0,0 +1,182 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/alphaflow/scope/util"
+)
+
+// SearchConfiguration is the postgres text search configuration used to parse both searchable columns and searches,
+// which is the default SearchConfiguration of NewConfig.
+var SearchConfiguration = "english"
+
+// SearchRelevanceColumn is the sort column that orders results by their relevance to the search in ForSortFromParams.
+const SearchRelevanceColumn = "relevance"
+
+// Weights that can be used within SearchColumn, from the most relevant to the least relevant.
+var searchWeights = map[string]string{
+	"A": "A",
+	"B": "B",
+	"C": "C",
+	"D": "D",
+}
+
+// SearchColumn represents a SQL statement that is searched by ForSearchFromParams.  Weight is one of the postgres text
+// search weights "A", "B", "C" or "D", where matches in columns weighted "A" are the most relevant.  Weight defaults to
+// "D".
+type SearchColumn struct {
+	Statement string
+	Weight    string
+}
+
+type SearchColumns []SearchColumn
+
+// Searchable is implemented by models with searchable columns that are not simple fields, ex. the columns of a related
+// table.  Fields can also be made searchable with the `search` tag, whose value is the weight of the field, ex.
+//
+//	Name string `json:"name" db:"name" search:"A"`
+type Searchable interface {
+	GetSearchColumns(ctx context.Context) SearchColumns
+}
+
+// GetAllSearchColumns is a utility in order to automatically get a list of all columns that are searched for the
+// referenced model.
+func GetAllSearchColumns(ctx context.Context, modelPtr interface{}) (SearchColumns, error) {
+	metadata, err := getModelMetadata(modelPtr, DialectFromContext(ctx), namingStrategyFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	searchColumns := make(SearchColumns, len(metadata.searchColumns))
+	copy(searchColumns, metadata.searchColumns)
+
+	if searchable, ok := modelPtr.(Searchable); ok {
+		searchColumns = append(searchColumns, searchable.GetSearchColumns(ctx)...)
+	}
+
+	return searchColumns, nil
+}
+
+// getTaggedSearchColumns returns the columns of the fields of a model with a `search` tag, quoted as needed for
+// `dialect` and named by `namer`.
+func getTaggedSearchColumns(modelPtr interface{}, dialect Dialect, namer schema.Namer) (SearchColumns, error) {
+	v := reflect.ValueOf(modelPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("pointer to struct expected")
+	}
+
+	// Fetch the associated table for this model.
+	tableName := tableName(modelPtr, namer)
+
+	searchColumns := SearchColumns{}
+
+	fields, err := modelFields(v.Elem().Interface(), namer)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		weight, ok := field.Tag.Lookup("search")
+		if !ok || weight == "-" || field.DBName == "" {
+			continue
+		}
+
+		searchColumns = append(searchColumns, SearchColumn{
+			Statement: quoteColumn(dialect, tableName, field.DBName),
+			Weight:    weight,
+		})
+	}
+
+	return searchColumns, nil
+}
+
+// ForSearchFromParams restricts a model to the results matching the `q` param.  Searches use the postgres
+// websearch_to_tsquery syntax, ex. `"exact phrase" -excluded or either`.
+func ForSearchFromParams(ctx context.Context, model interface{}, params buffalo.ParamValues) (ScopeFunc, error) {
+	ctx, params = withParamsConfig(ctx, params, nil)
+
+	// If nothing is specified, this is a no-op.
+	if util.IsBlank(params.Get("q")) {
+		return func(q *gorm.DB) *gorm.DB {
+			return q
+		}, nil
+	}
+
+	searchVector, err := getSearchVector(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	search := params.Get("q")
+	return func(q *gorm.DB) *gorm.DB {
+		if dialect := getDialect(q); !dialect.Supports(FeatureSearch) {
+			_ = q.AddError(newParamError(ParamErrorCodeUnsupported, "q", search, "invalid search: search is not supported by %v", dialect.Name()))
+			return q
+		}
+
+		return q.Where(fmt.Sprintf("(%v) @@ %v", searchVector, getSearchQuery(ctx)), search)
+	}, nil
+}
+
+// getSearchRank returns a statement ranking a model by the relevance of its searchable columns to the `q` param, and
+// its args.
+func getSearchRank(ctx context.Context, model interface{}, params buffalo.ParamValues) (string, []interface{}, error) {
+	if util.IsBlank(params.Get("q")) {
+		return "", nil, newParamError(ParamErrorCodeUnsupported, "", SearchRelevanceColumn, "invalid sort field: %v requires a search", SearchRelevanceColumn)
+	}
+
+	searchVector, err := getSearchVector(ctx, model)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("ts_rank(%v, %v)", searchVector, getSearchQuery(ctx)), []interface{}{params.Get("q")}, nil
+}
+
+// getSearchVector returns a statement building the weighted tsvector of all the searchable columns of a model.
+func getSearchVector(ctx context.Context, model interface{}) (string, error) {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Struct {
+		return "", errors.New("struct expected")
+	}
+	modelPtr := reflect.New(reflect.TypeOf(model)).Interface()
+
+	searchColumns, err := GetAllSearchColumns(ctx, modelPtr)
+	if err != nil {
+		return "", err
+	}
+
+	if len(searchColumns) == 0 {
+		return "", errors.New("model has no search columns")
+	}
+
+	vectors := make([]string, len(searchColumns))
+	for i, searchColumn := range searchColumns {
+		weight := "D"
+		if !util.IsBlank(searchColumn.Weight) {
+			var ok bool
+			weight, ok = searchWeights[strings.ToUpper(searchColumn.Weight)]
+			if !ok {
+				return "", errors.Errorf("invalid search weight: %v", searchColumn.Weight)
+			}
+		}
+
+		// Null columns would make the whole vector null, so they are treated as empty.
+		vectors[i] = fmt.Sprintf("setweight(to_tsvector('%v', COALESCE(CAST(%v AS TEXT), '')), '%v')", ConfigFromContext(ctx).SearchConfiguration, searchColumn.Statement, weight)
+	}
+
+	return strings.Join(vectors, " || "), nil
+}
+
+// getSearchQuery returns a statement parsing the search arg into a tsquery.
+func getSearchQuery(ctx context.Context) string {
+	return fmt.Sprintf("websearch_to_tsquery('%v', ?)", ConfigFromContext(ctx).SearchConfiguration)
+}
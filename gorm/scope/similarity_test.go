@@ -0,0 +1,140 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope/gorm/scope"
+)
+
+func (ss *ScopesSuite) createNamedDocuments() (*TestDocument, *TestDocument, *TestDocument) {
+	jonathan := &TestDocument{ID: uuid.Must(uuid.NewV4()), Title: "Jonathan"}
+	err := ss.DB.Create(jonathan).Error
+	ss.NoError(err)
+
+	johnathan := &TestDocument{ID: uuid.Must(uuid.NewV4()), Title: "Johnathan"}
+	err = ss.DB.Create(johnathan).Error
+	ss.NoError(err)
+
+	mary := &TestDocument{ID: uuid.Must(uuid.NewV4()), Title: "Mary"}
+	err = ss.DB.Create(mary).Error
+	ss.NoError(err)
+
+	return jonathan, johnathan, mary
+}
+
+func (ss *ScopesSuite) TestCheckSimilarityExtension() {
+	ss.skipUnlessSupports("SIM")
+
+	err := scope.CheckSimilarityExtension(ss.DB)
+	ss.NoError(err)
+}
+
+// sqlStateError is a query error with a SQLSTATE, like the errors of the postgres driver.
+type sqlStateError struct {
+	message  string
+	sqlState string
+}
+
+func (e *sqlStateError) Error() string {
+	return e.message
+}
+
+func (e *sqlStateError) SQLState() string {
+	return e.sqlState
+}
+
+func (ss *ScopesSuite) TestSimilarityError() {
+	ss.NoError(scope.SimilarityError(nil))
+
+	err := &sqlStateError{message: "ERROR: function similarity(text, unknown) does not exist (SQLSTATE 42883)", sqlState: "42883"}
+	ss.Equal(scope.ErrSimilarityUnavailable, scope.SimilarityError(err))
+
+	err = &sqlStateError{message: "ERROR: operator does not exist: text % unknown (SQLSTATE 42883)", sqlState: "42883"}
+	ss.Equal(scope.ErrSimilarityUnavailable, scope.SimilarityError(err))
+
+	// Other missing functions, and other errors, are returned unchanged.
+	err = &sqlStateError{message: "ERROR: function nope(text) does not exist (SQLSTATE 42883)", sqlState: "42883"}
+	ss.Equal(err, scope.SimilarityError(err))
+
+	otherErr := errors.New("similarity( failed")
+	ss.Equal(otherErr, scope.SimilarityError(otherErr))
+}
+
+func (ss *ScopesSuite) TestForFiltersFromParams_similarity() {
+	ss.skipUnlessSupports("SIM")
+
+	jonathan, johnathan, _ := ss.createNamedDocuments()
+
+	testCases := []struct {
+		Value     string
+		Threshold string
+		Expected  []uuid.UUID
+	}{
+		{Value: "Jonathan", Expected: []uuid.UUID{jonathan.ID, johnathan.ID}},
+		{Value: "jonathon", Expected: []uuid.UUID{jonathan.ID, johnathan.ID}},
+		{Value: "Jonathan", Threshold: "1", Expected: []uuid.UUID{jonathan.ID}},
+		{Value: "Bob", Expected: []uuid.UUID{}},
+	}
+
+	for _, testCase := range testCases {
+		params := url.Values{
+			"filter_columns":              {"title"},
+			"filter_types":                {"SIM"},
+			"filter_values":               {testCase.Value},
+			"filter_similarity_threshold": {testCase.Threshold},
+		}
+
+		s, err := scope.ForFiltersFromParams(context.Background(), TestDocument{}, params)
+		ss.NoError(err)
+
+		documents := []TestDocument{}
+		err = ss.DB.Scopes(s).Find(&documents).Error
+		ss.NoError(err)
+
+		ids := make([]uuid.UUID, len(documents))
+		for i := range documents {
+			ids[i] = documents[i].ID
+		}
+
+		ss.ElementsMatch(testCase.Expected, ids, testCase.Value)
+	}
+}
+
+func (ss *ScopesSuite) TestForSortFromParams_similarity() {
+	ss.skipUnlessSupports("SIM")
+
+	jonathan, johnathan, mary := ss.createNamedDocuments()
+
+	// The most similar values are listed first.
+	params := url.Values{
+		"sort_columns":    {"title"},
+		"sort_directions": {"SIM"},
+		"sort_values":     {"Johnathan"},
+	}
+
+	s, err := scope.ForSortFromParams(context.Background(), TestDocument{}, params)
+	ss.NoError(err)
+
+	documents := []TestDocument{}
+	err = ss.DB.Scopes(s).Find(&documents).Error
+	ss.NoError(err)
+	ss.Len(documents, 3)
+	ss.Equal(johnathan.ID, documents[0].ID)
+	ss.Equal(jonathan.ID, documents[1].ID)
+	ss.Equal(mary.ID, documents[2].ID)
+
+	// Values are quoted safely within the sort.
+	params["sort_values"] = []string{`Mary's \ name`}
+	s, err = scope.ForSortFromParams(context.Background(), TestDocument{}, params)
+	ss.NoError(err)
+
+	documents = []TestDocument{}
+	err = ss.DB.Scopes(s).Find(&documents).Error
+	ss.NoError(err)
+	ss.Len(documents, 3)
+	ss.Equal(mary.ID, documents[0].ID)
+}
@@ -0,0 +1,100 @@
+package scope
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alphaflow/scope/util"
+)
+
+// jsonPathTag is the value of the `filter` tag of JSONB fields whose keys can be filtered on by path, ex.
+//
+//	Metadata string `json:"metadata" db:"metadata" filter:"path"`
+const jsonPathTag = "path"
+
+// jsonPathSeparator separates the column from each key of a path, ex. `metadata.source.name`.
+const jsonPathSeparator = "."
+
+// jsonPathCastSeparator separates a path from the type its value is cast to, ex. `metadata.count::numeric`.
+const jsonPathCastSeparator = "::"
+
+// Types that the value of a path can be cast to.  Values are text by default.
+var jsonPathCasts = map[string]string{
+	"TEXT":        "TEXT",
+	"NUMERIC":     "NUMERIC",
+	"INTEGER":     "INTEGER",
+	"BOOLEAN":     "BOOLEAN",
+	"DATE":        "DATE",
+	"TIMESTAMP":   "TIMESTAMP",
+	"TIMESTAMPTZ": "TIMESTAMPTZ",
+}
+
+// Keys of a path are restricted to safe characters, since they are part of the statement rather than args.
+var jsonPathKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// getAllJSONPathColumnNames returns the json names of all fields of a model that can be filtered on by path.
+func getAllJSONPathColumnNames(model interface{}) (map[string]bool, error) {
+	fields, err := util.ModelFields(model)
+	if err != nil {
+		return nil, err
+	}
+
+	pathColumnNames := make(map[string]bool)
+	for _, field := range fields {
+		if field.JSONName == "" || field.Tag.Get("filter") != jsonPathTag {
+			continue
+		}
+
+		pathColumnNames[field.JSONName] = true
+	}
+
+	return pathColumnNames, nil
+}
+
+// getJSONPathStatement returns the statement of the value at a path, ex. `metadata.source.name` is resolved to
+// `(objects.metadata #>> '{source,name}')`.  The value is text, unless it is cast, or the filter type is a JSONB
+// filter type, in which case the value is JSONB.  If `name` is not a path of one of the `pathColumnNames`, ok is false.
+func getJSONPathStatement(name string, columnMap map[string]string, pathColumnNames map[string]bool, filterType string) (stmt string, ok bool, err error) {
+	path, cast := name, ""
+	if i := strings.Index(name, jsonPathCastSeparator); i >= 0 {
+		path, cast = name[:i], name[i+len(jsonPathCastSeparator):]
+	}
+
+	keys := strings.Split(path, jsonPathSeparator)
+	if len(keys) < 2 || !pathColumnNames[keys[0]] {
+		return "", false, nil
+	}
+
+	columnStatement, ok := columnMap[keys[0]]
+	if !ok {
+		return "", false, nil
+	}
+
+	for _, key := range keys[1:] {
+		if !jsonPathKeyRegex.MatchString(key) {
+			return "", false, newParamError(ParamErrorCodeInvalidField, "", name, "invalid filter path: %v", name)
+		}
+	}
+
+	pathLiteral := fmt.Sprintf("'{%v}'", strings.Join(keys[1:], ","))
+
+	if filterOperatorIsJSON(filterType) {
+		if cast != "" {
+			return "", false, newParamError(ParamErrorCodeUnsupported, "", name, "invalid filter path: %v cannot be cast for filter type %v", name, filterType)
+		}
+
+		return fmt.Sprintf("(%v #> %v)", columnStatement, pathLiteral), true, nil
+	}
+
+	if cast == "" {
+		return fmt.Sprintf("(%v #>> %v)", columnStatement, pathLiteral), true, nil
+	}
+
+	castType, ok := jsonPathCasts[strings.ToUpper(cast)]
+	if !ok {
+		return "", false, newParamError(ParamErrorCodeInvalidType, "", name, "invalid filter path cast: %v", cast)
+	}
+
+	return fmt.Sprintf("CAST(%v #>> %v AS %v)", columnStatement, pathLiteral, castType), true, nil
+}
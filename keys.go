@@ -0,0 +1,320 @@
+package scope
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope/util"
+)
+
+// Key is the value of the primary key of a row, with one value per column of the primary key, ex. `Key{int64(42)}`.
+type Key []interface{}
+
+// KeySet is a set of primary keys, which may be of any type and have any number of columns.  Keys are compared by
+// their values and types, so `Key{int64(42)}` and `Key{42}` are different keys of a set, but scopes for a set convert
+// its keys to the types of the primary key and match each converted key once.
+type KeySet map[string]Key
+
+// NewKeySet returns a set of keys.
+func NewKeySet(keys ...Key) KeySet {
+	keySet := KeySet{}
+	for _, key := range keys {
+		keySet.Add(key)
+	}
+
+	return keySet
+}
+
+// Add adds a key to the set.
+func (keySet KeySet) Add(key Key) {
+	keySet[keySetIndex(key)] = key
+}
+
+// Contains returns true if the key is in the set.
+func (keySet KeySet) Contains(key Key) bool {
+	_, ok := keySet[keySetIndex(key)]
+	return ok
+}
+
+// Keys returns the keys of the set, in a consistent order.
+func (keySet KeySet) Keys() []Key {
+	indexes := make([]string, 0, len(keySet))
+	for index := range keySet {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+
+	keys := make([]Key, len(indexes))
+	for i, index := range indexes {
+		keys[i] = keySet[index]
+	}
+
+	return keys
+}
+
+// keySetIndex returns the index of a key in a KeySet, which includes the types of its values.
+func keySetIndex(key Key) string {
+	values := make([]string, len(key))
+	for i, value := range key {
+		values[i] = fmt.Sprintf("%T(%#v)", value, value)
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// PrimaryKeyable is implemented by models whose primary key is not their ID field, ex. a composite key.
+type PrimaryKeyable interface {
+	PrimaryKeyColumns() []string
+}
+
+// PrimaryKey is the primary key of the table of a model, which has the columns `Columns` of the types `Types`.
+type PrimaryKey struct {
+	TableName string
+	Columns   []string
+	Types     []reflect.Type
+}
+
+// GetPrimaryKey returns the primary key of a model.  The primary key is the ID field of the model, as it is for pop,
+// unless the model implements PrimaryKeyable.
+func GetPrimaryKey(model interface{}) (*PrimaryKey, error) {
+	if model == nil {
+		return nil, errors.New("struct expected")
+	}
+
+	v, t := util.StructValueAndType(model)
+	if v.Kind() != reflect.Struct {
+		return nil, errors.New("struct expected")
+	}
+
+	primaryKey := &PrimaryKey{TableName: (&pop.Model{Value: model}).TableName()}
+
+	primaryKeyable, ok := model.(PrimaryKeyable)
+	if !ok {
+		primaryKeyable, ok = v.Interface().(PrimaryKeyable)
+	}
+
+	if !ok {
+		field, ok := t.FieldByName("ID")
+		if !ok {
+			return nil, errors.Errorf("model %v is missing required field ID", t)
+		}
+
+		primaryKey.Columns = []string{(&pop.Model{Value: model}).IDField()}
+		primaryKey.Types = []reflect.Type{field.Type}
+		return primaryKey, nil
+	}
+
+	fields, err := util.ModelFields(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, column := range primaryKeyable.PrimaryKeyColumns() {
+		found := false
+		for _, field := range fields {
+			if field.DBName == column {
+				primaryKey.Columns = append(primaryKey.Columns, column)
+				primaryKey.Types = append(primaryKey.Types, field.Type)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errors.Errorf("invalid primary key column: %v is not a column of %v", column, t)
+		}
+	}
+
+	if len(primaryKey.Columns) == 0 {
+		return nil, errors.Errorf("invalid primary key: %v has no primary key columns", t)
+	}
+
+	return primaryKey, nil
+}
+
+// ParseKey parses a key from the string value of each of its columns, ex. an ID from a URL.
+func (pk *PrimaryKey) ParseKey(values ...string) (Key, error) {
+	if len(values) != len(pk.Columns) {
+		return nil, pk.keyLengthError(len(values), strings.Join(values, ","))
+	}
+
+	key := make(Key, len(values))
+	for i, value := range values {
+		var err error
+		key[i], err = parseKeyValue(pk.Columns[i], pk.Types[i], value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// normalizeKey converts the values of a key to the types of the primary key columns, parsing string values if needed.
+func (pk *PrimaryKey) normalizeKey(key Key) (Key, error) {
+	if len(key) != len(pk.Columns) {
+		return nil, pk.keyLengthError(len(key), fmt.Sprint(key))
+	}
+
+	normalized := make(Key, len(key))
+	for i, value := range key {
+		t := pk.Types[i]
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		v := reflect.ValueOf(value)
+		switch {
+		case !v.IsValid():
+			return nil, newParamError(ParamErrorCodeInvalidValue, pk.Columns[i], "", "invalid key: %v is null", pk.Columns[i])
+		case v.Type() == t:
+			normalized[i] = value
+		case v.Kind() == reflect.String:
+			parsed, err := parseKeyValue(pk.Columns[i], t, v.String())
+			if err != nil {
+				return nil, err
+			}
+			normalized[i] = parsed
+		case v.Type().ConvertibleTo(t) && v.Kind() != reflect.String && t.Kind() != reflect.String:
+			normalized[i] = v.Convert(t).Interface()
+		default:
+			return nil, newParamError(ParamErrorCodeInvalidValue, pk.Columns[i], fmt.Sprint(value), "invalid key: %v is not a valid %v", value, t)
+		}
+	}
+
+	return normalized, nil
+}
+
+// clause returns the clause matching the rows whose primary key is in `keys`, or not in `keys` if `not` is set, and
+// its args.  Composite keys are compared as row values, ex. `(order_id, line) IN ((?, ?), (?, ?))`.
+func (pk *PrimaryKey) clause(dialect Dialect, keys []Key, not bool) (string, []interface{}) {
+	columns := make([]string, len(pk.Columns))
+	for i, column := range pk.Columns {
+		columns[i] = quoteColumn(dialect, pk.TableName, column)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	column := strings.Join(columns, ", ")
+	if len(columns) > 1 {
+		column = fmt.Sprintf("(%v)", column)
+		placeholders = fmt.Sprintf("(%v)", placeholders)
+	}
+
+	tuples := make([]string, len(keys))
+	args := make([]interface{}, 0, len(keys)*len(columns))
+	for i, key := range keys {
+		tuples[i] = placeholders
+		args = append(args, key...)
+	}
+
+	operator := "IN"
+	if not {
+		operator = "NOT IN"
+	}
+
+	return fmt.Sprintf("%v %v (%v)", column, operator, strings.Join(tuples, ", ")), args
+}
+
+// keyLengthError returns the ParamError of a key with `n` values, which doesn't have a value for each primary key
+// column.
+func (pk *PrimaryKey) keyLengthError(n int, value string) error {
+	return newParamError(ParamErrorCodeMismatchedParams, strings.Join(pk.Columns, ","), value,
+		"invalid key: %v values for %v primary key columns", n, len(pk.Columns))
+}
+
+// parseKeyValue parses the string value of the primary key column `column` of the type t.  An invalid value is a
+// ParamError, but an unsupported type is not, since it is an error of the model.
+func parseKeyValue(column string, t reflect.Type, value string) (interface{}, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(uuid.UUID{}) {
+		id, err := uuid.FromString(value)
+		if err != nil {
+			return nil, newParamError(ParamErrorCodeInvalidValue, column, value, "invalid key: %v", value)
+		}
+		return id, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(value).Convert(t).Interface(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, t.Bits())
+		if err != nil {
+			return nil, newParamError(ParamErrorCodeInvalidValue, column, value, "invalid key: %v", value)
+		}
+		return reflect.ValueOf(n).Convert(t).Interface(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, t.Bits())
+		if err != nil {
+			return nil, newParamError(ParamErrorCodeInvalidValue, column, value, "invalid key: %v", value)
+		}
+		return reflect.ValueOf(n).Convert(t).Interface(), nil
+	default:
+		return nil, errors.Errorf("unsupported primary key type: %v", t)
+	}
+}
+
+// ForKeyForModel scopes a query to the row of a model with a primary key.  The values of the key are converted to the
+// types of the primary key columns, and strings are parsed, ex. `Key{"42"}` for an int64 ID.
+func ForKeyForModel(key Key, model interface{}) (pop.ScopeFunc, error) {
+	return forKeysForModel([]Key{key}, model, false)
+}
+
+// ForKeySetForModel scopes a query to the rows of a model with any of the primary keys of a set.  An empty set matches
+// no rows.
+func ForKeySetForModel(keySet KeySet, model interface{}) (pop.ScopeFunc, error) {
+	return forKeysForModel(keySet.Keys(), model, false)
+}
+
+// ForNotKeyForModel scopes a query to the rows of a model without a primary key.
+func ForNotKeyForModel(key Key, model interface{}) (pop.ScopeFunc, error) {
+	return forKeysForModel([]Key{key}, model, true)
+}
+
+// ForNotKeySetForModel scopes a query to the rows of a model without any of the primary keys of a set.  An empty set
+// matches all rows.
+func ForNotKeySetForModel(keySet KeySet, model interface{}) (pop.ScopeFunc, error) {
+	return forKeysForModel(keySet.Keys(), model, true)
+}
+
+// forKeysForModel scopes a query to the rows of a model with, or without if `not` is set, any of the primary keys.
+func forKeysForModel(keys []Key, model interface{}, not bool) (pop.ScopeFunc, error) {
+	primaryKey, err := GetPrimaryKey(model)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys that differ by type may be the same key once converted, ex. `Key{42}` and `Key{int64(42)}`.
+	normalizedKeySet := KeySet{}
+	for _, key := range keys {
+		normalizedKey, err := primaryKey.normalizeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		normalizedKeySet.Add(normalizedKey)
+	}
+	normalizedKeys := normalizedKeySet.Keys()
+
+	return func(q *pop.Query) *pop.Query {
+		if len(normalizedKeys) == 0 {
+			if not {
+				return q
+			}
+
+			return q.Where("1 = 0")
+		}
+
+		clause, args := primaryKey.clause(getDialect(q.Connection), normalizedKeys, not)
+		return q.Where(clause, args...)
+	}, nil
+}
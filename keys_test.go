@@ -0,0 +1,110 @@
+package scope_test
+
+import (
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope"
+)
+
+type TestLegacyObject struct {
+	ID   int64  `json:"id" db:"legacy_id"`
+	Name string `json:"name" db:"name"`
+}
+
+func (t TestLegacyObject) TableName() string {
+	return "legacy_objects"
+}
+
+type TestLineItem struct {
+	OrderID int64  `json:"order_id" db:"order_id"`
+	Line    int    `json:"line" db:"line"`
+	Name    string `json:"name" db:"name"`
+}
+
+func (t TestLineItem) TableName() string {
+	return "line_items"
+}
+
+func (t TestLineItem) PrimaryKeyColumns() []string {
+	return []string{"order_id", "line"}
+}
+
+func (ss *ScopesSuite) TestKeySet() {
+	keySet := scope.NewKeySet(scope.Key{int64(1), 2}, scope.Key{int64(1), 1}, scope.Key{int64(1), 2})
+	ss.Len(keySet, 2)
+	ss.True(keySet.Contains(scope.Key{int64(1), 1}))
+	ss.False(keySet.Contains(scope.Key{1, 1}))
+	ss.Equal([]scope.Key{{int64(1), 1}, {int64(1), 2}}, keySet.Keys())
+}
+
+func (ss *ScopesSuite) TestGetPrimaryKey() {
+	primaryKey, err := scope.GetPrimaryKey(&TestLegacyObject{})
+	ss.NoError(err)
+	ss.Equal("legacy_objects", primaryKey.TableName)
+	ss.Equal([]string{"legacy_id"}, primaryKey.Columns)
+
+	key, err := primaryKey.ParseKey("42")
+	ss.NoError(err)
+	ss.Equal(scope.Key{int64(42)}, key)
+
+	_, err = primaryKey.ParseKey("forty-two")
+	var paramErr *scope.ParamError
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal("legacy_id", paramErr.Param)
+
+	primaryKey, err = scope.GetPrimaryKey(TestLineItem{})
+	ss.NoError(err)
+	ss.Equal([]string{"order_id", "line"}, primaryKey.Columns)
+
+	key, err = primaryKey.ParseKey("42", "3")
+	ss.NoError(err)
+	ss.Equal(scope.Key{int64(42), 3}, key)
+
+	_, err = primaryKey.ParseKey("42")
+	ss.Error(err)
+
+	_, err = scope.GetPrimaryKey(struct{ Name string }{})
+	ss.Error(err)
+}
+
+func (ss *ScopesSuite) TestForKeyForModel() {
+	s, err := scope.ForKeyForModel(scope.Key{"42"}, TestLegacyObject{})
+	ss.NoError(err)
+
+	query, args := ss.dryRunDB("postgres").Q().Scope(s).ToSQL(&pop.Model{Value: TestLegacyObject{}})
+	ss.Contains(query, "WHERE legacy_objects.legacy_id IN ($1)")
+	ss.Equal([]interface{}{int64(42)}, args)
+
+	s, err = scope.ForNotKeySetForModel(scope.NewKeySet(scope.Key{42, 1}, scope.Key{42, 2}), TestLineItem{})
+	ss.NoError(err)
+
+	query, args = ss.dryRunDB("postgres").Q().Scope(s).ToSQL(&pop.Model{Value: TestLineItem{}})
+	ss.Contains(query, "WHERE (line_items.order_id, line_items.line) NOT IN (($1, $2), ($3, $4))")
+	ss.Equal([]interface{}{int64(42), 1, int64(42), 2}, args)
+
+	s, err = scope.ForKeySetForModel(scope.NewKeySet(), TestLineItem{})
+	ss.NoError(err)
+
+	query, _ = ss.dryRunDB("postgres").Q().Scope(s).ToSQL(&pop.Model{Value: TestLineItem{}})
+	ss.Contains(query, "WHERE 1 = 0")
+
+	s, err = scope.ForKeySetForModel(scope.NewKeySet(scope.Key{42}, scope.Key{int64(42)}, scope.Key{"42"}), TestLegacyObject{})
+	ss.NoError(err)
+
+	query, args = ss.dryRunDB("postgres").Q().Scope(s).ToSQL(&pop.Model{Value: TestLegacyObject{}})
+	ss.Contains(query, "WHERE legacy_objects.legacy_id IN ($1)")
+	ss.Equal([]interface{}{int64(42)}, args)
+
+	// Invalid keys are errors of the request.
+	_, err = scope.ForKeyForModel(scope.Key{"forty-two"}, TestLegacyObject{})
+	var paramErr *scope.ParamError
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeInvalidValue, paramErr.Code)
+	ss.Equal("legacy_id", paramErr.Param)
+	ss.Equal("forty-two", paramErr.Value)
+
+	_, err = scope.ForKeyForModel(scope.Key{42}, TestLineItem{})
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeMismatchedParams, paramErr.Code)
+}
@@ -0,0 +1,164 @@
+package scope
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+)
+
+// Limits restrict the complexity of the queries that are built from params, so that a single request can't generate a
+// pathological query.  A limit of 0 is unlimited.
+type Limits struct {
+	// MaxFilterClauses is the maximum number of `filter_columns`.
+	MaxFilterClauses int
+	// MaxFilterValues is the maximum number of values of a single IN, NIN or array filter.
+	MaxFilterValues int
+	// MaxFilterParenDepth is the maximum depth of nested filter parentheses.
+	MaxFilterParenDepth int
+	// MaxSortColumns is the maximum number of `sort_columns`.
+	MaxSortColumns int
+	// MaxAggregations is the maximum number of aggregations of a single request.
+	MaxAggregations int
+	// RejectLeadingWildcards rejects LK, ILK, NLK and NILK filters whose values start with a wildcard, which can't use
+	// an index.
+	RejectLeadingWildcards bool
+	// MaxQueryCost is the maximum planner cost of the queries of the aggregations, filter facets and filter options, see
+	// CheckQueryCost.
+	MaxQueryCost float64
+}
+
+// DefaultLimits are the Limits of NewConfig, which are unlimited so that existing queries aren't rejected.  Limits can
+// be enabled by setting them, ex. to RecommendedLimits.
+var DefaultLimits = Limits{}
+
+// RecommendedLimits are limits that allow the queries of typical requests, but not pathological ones.
+var RecommendedLimits = Limits{
+	MaxFilterClauses:    50,
+	MaxFilterValues:     1000,
+	MaxFilterParenDepth: 10,
+	MaxSortColumns:      10,
+	MaxAggregations:     20,
+}
+
+// limitsContextKey is the context key of the limits set by WithLimits.
+type limitsContextKey struct{}
+
+// WithLimits returns a copy of a context in which queries are restricted by limits, see LimitsFromContext.
+func WithLimits(ctx context.Context, limits Limits) context.Context {
+	return context.WithValue(ctx, limitsContextKey{}, limits)
+}
+
+// LimitsFromContext returns the limits of the queries built within a context, which are the Limits of the config of the
+// context unless they are set by WithLimits.
+func LimitsFromContext(ctx context.Context) Limits {
+	if ctx != nil {
+		if limits, ok := ctx.Value(limitsContextKey{}).(Limits); ok {
+			return limits
+		}
+	}
+
+	return ConfigFromContext(ctx).Limits
+}
+
+// exceeds returns true if `n` exceeds the limit `max`.
+func exceeds(n, max int) bool {
+	return max > 0 && n > max
+}
+
+// filterParenDepth returns the maximum depth of the parentheses of filter clauses, where `leftParens` and
+// `rightParens` are the parentheses before and after each clause.
+func filterParenDepth(clauseCount int, leftParens, rightParens map[int]string) int {
+	depth, maxDepth := 0, 0
+	for i := 0; i < clauseCount; i++ {
+		depth += len(leftParens[i])
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		depth -= len(rightParens[i])
+	}
+
+	return maxDepth
+}
+
+// hasLeadingWildcard returns true if the value of a LIKE filter starts with a wildcard.
+func hasLeadingWildcard(filterType, value string) bool {
+	switch strings.ToUpper(filterType) {
+	case "LK", "ILK", "NLK", "NILK":
+		return strings.HasPrefix(value, "%") || strings.HasPrefix(value, "_")
+	}
+
+	return false
+}
+
+// queryPlan is a row of the result of `EXPLAIN (FORMAT JSON)`.
+type queryPlan struct {
+	Plan string `db:"QUERY PLAN"`
+}
+
+// CheckQueryCost returns an error if the planner cost of the query of modelsPtr, restricted by the scope collection
+// scopes, exceeds the MaxQueryCost of the limits of the context.  This can be used to reject an expensive query before
+// it is executed.  The cost is only checked if MaxQueryCost is set, and the dialect of the connection supports
+// FeatureQueryCost.
+func CheckQueryCost(ctx context.Context, tx *pop.Connection, modelsPtr interface{}, scopes *Collection) error {
+	ctx = withCollectionConfig(ctx, scopes)
+
+	if LimitsFromContext(ctx).MaxQueryCost <= 0 {
+		return nil
+	}
+
+	q := tx.Q()
+	if scopes != nil {
+		q = q.Scope(scopes.Flatten())
+	}
+
+	statement, args := q.ToSQL(&pop.Model{Value: modelsPtr})
+	return checkQueryCost(ctx, tx, statement, args...)
+}
+
+// checkQueryCost returns an error if the planner cost of a statement exceeds the MaxQueryCost of the limits of the
+// context.
+func checkQueryCost(ctx context.Context, tx *pop.Connection, statement string, args ...interface{}) error {
+	maxCost := LimitsFromContext(ctx).MaxQueryCost
+	if maxCost <= 0 || !getDialect(tx).Supports(FeatureQueryCost) {
+		return nil
+	}
+
+	plans := []queryPlan{}
+	err := tx.RawQuery("EXPLAIN (FORMAT JSON) "+statement, args...).All(&plans)
+	if err != nil {
+		return err
+	}
+
+	if len(plans) == 0 {
+		return errors.New("missing query plan")
+	}
+
+	return checkQueryPlanCost(plans[0].Plan, maxCost)
+}
+
+// checkQueryPlanCost returns an error if the total cost of the plan of a query exceeds `maxCost`, where the plan is the
+// result of `EXPLAIN (FORMAT JSON)`, ex. `[{"Plan": {"Total Cost": 12.5}}]`.
+func checkQueryPlanCost(plan string, maxCost float64) error {
+	var parsed []struct {
+		Plan struct {
+			TotalCost float64 `json:"Total Cost"`
+		} `json:"Plan"`
+	}
+
+	if err := json.Unmarshal([]byte(plan), &parsed); err != nil {
+		return errors.Wrap(err, "invalid query plan")
+	}
+
+	if len(parsed) == 0 {
+		return errors.New("missing query plan")
+	}
+
+	if cost := parsed[0].Plan.TotalCost; cost > maxCost {
+		return newParamError(ParamErrorCodeLimitExceeded, "", "", "query too expensive: cost %v exceeds %v", cost, maxCost)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope"
+)
+
+func (ss *ScopesSuite) TestForFiltersFromParams_Limits() {
+	testCases := []struct {
+		Limits scope.Limits
+		Params url.Values
+		Param  string
+		Index  int
+	}{
+		{
+			Limits: scope.Limits{MaxFilterClauses: 2},
+			Params: url.Values{"filter_columns": {"id|id|id"}, "filter_types": {"nn|nn|nn"}, "filter_values": {"||"}, "filter_logic": {"and|and"}},
+			Param:  "filter_columns",
+			Index:  -1,
+		},
+		{
+			Limits: scope.Limits{MaxFilterValues: 2},
+			Params: url.Values{"filter_columns": {"id|id"}, "filter_types": {"nn|in"}, "filter_values": {"|a,b,c"}, "filter_logic": {"and"}},
+			Param:  "filter_values",
+			Index:  1,
+		},
+		{
+			Limits: scope.Limits{MaxFilterParenDepth: 1},
+			Params: url.Values{"filter_columns": {"id|id"}, "filter_types": {"nn|nu"}, "filter_values": {"|"}, "filter_logic": {"or"}, "filter_left_parens": {"0|0"}, "filter_right_parens": {"1|1"}},
+			Param:  "filter_left_parens",
+			Index:  -1,
+		},
+		{
+			Limits: scope.Limits{RejectLeadingWildcards: true},
+			Params: url.Values{"filter_columns": {"id"}, "filter_types": {"ilk"}, "filter_values": {"%12"}},
+			Param:  "filter_values",
+			Index:  0,
+		},
+	}
+
+	for _, tc := range testCases {
+		// The params are valid without limits.
+		_, err := scope.ForFiltersFromParams(scope.WithLimits(context.Background(), scope.Limits{}), TestModel{}, tc.Params)
+		ss.NoError(err)
+
+		_, err = scope.ForFiltersFromParams(scope.WithLimits(context.Background(), tc.Limits), TestModel{}, tc.Params)
+		ss.Error(err)
+
+		var paramErr *scope.ParamError
+		ss.True(errors.As(err, &paramErr))
+		ss.Equal(scope.ParamErrorCodeLimitExceeded, paramErr.Code, err.Error())
+		ss.Equal(tc.Param, paramErr.Param, err.Error())
+		ss.Equal(tc.Index, paramErr.Index, err.Error())
+	}
+}
+
+func (ss *ScopesSuite) TestForSortFromParams_Limits() {
+	params := url.Values{"sort_columns": {"id|custom_sort"}, "sort_directions": {"asc|desc"}}
+
+	_, err := scope.ForSortFromParams(context.Background(), TestModel{}, params)
+	ss.NoError(err)
+
+	_, err = scope.ForSortFromParams(scope.WithLimits(context.Background(), scope.Limits{MaxSortColumns: 1}), TestModel{}, params)
+	ss.EqualError(err, "too many sort columns: more than 1")
+}
+
+func (ss *ScopesSuite) TestGetAggregationsFromParams_Limits() {
+	params := url.Values{"aggregation_column": {"id|id"}, "aggregation_type": {"count|max"}}
+
+	ctx := scope.WithLimits(context.Background(), scope.Limits{MaxAggregations: 1})
+	_, err := scope.GetAggregationsFromParams(ctx, ss.DB, &[]TestObject{}, params, nil)
+	ss.EqualError(err, "too many aggregations: more than 1")
+
+	_, err = scope.GetGroupedAggregationsFromParams(ctx, ss.DB, &[]TestObject{}, params, nil)
+	ss.EqualError(err, "too many aggregations: more than 1")
+}
+
+func (ss *ScopesSuite) TestLimitsFromContext() {
+	// Queries are unlimited by default.
+	ss.Equal(scope.Limits{}, scope.LimitsFromContext(context.Background()))
+	ss.Equal(scope.Limits{MaxSortColumns: 1}, scope.LimitsFromContext(scope.WithLimits(context.Background(), scope.Limits{MaxSortColumns: 1})))
+}
+
+func (ss *ScopesSuite) TestCheckQueryCost() {
+	ss.skipUnlessSupports(scope.FeatureQueryCost)
+
+	sc := scope.NewCollection(ss.DB)
+	sc.Push(scope.ForNotNull("test_objects.num"))
+
+	// The cost is only checked if a maximum is set.
+	ss.NoError(scope.CheckQueryCost(context.Background(), ss.DB, &[]TestObject{}, sc))
+
+	err := scope.CheckQueryCost(scope.WithLimits(context.Background(), scope.Limits{MaxQueryCost: 1e9}), ss.DB, &[]TestObject{}, sc)
+	ss.NoError(err)
+
+	err = scope.CheckQueryCost(scope.WithLimits(context.Background(), scope.Limits{MaxQueryCost: 0.001}), ss.DB, &[]TestObject{}, sc)
+	var paramErr *scope.ParamError
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeLimitExceeded, paramErr.Code)
+
+	// Aggregations are checked before they are executed.
+	params := url.Values{"aggregation_column": {"id"}, "aggregation_type": {"count"}}
+	_, err = scope.GetAggregationsFromParams(scope.WithLimits(context.Background(), scope.Limits{MaxQueryCost: 0.001}), ss.DB, &[]TestObject{}, params, nil)
+	ss.True(errors.As(err, &paramErr))
+	ss.Equal(scope.ParamErrorCodeLimitExceeded, paramErr.Code)
+}
@@ -0,0 +1,132 @@
+package scope
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/gobuffalo/pop/v5"
+)
+
+// modelMetadata is the metadata of a model type that is derived from its struct tags, which is computed once per type
+// by getModelMetadata.  Custom columns are not included, since they may depend on the context of each request.
+type modelMetadata struct {
+	// columns are the columns of the fields of the model, and columnIndexes are their indexes by name.
+	columns       []CustomColumn
+	columnIndexes map[string]int
+
+	// pathColumnNames are the names of the columns that can be filtered on by JSON path.
+	pathColumnNames map[string]bool
+
+	// searchColumns are the columns of the fields of the model with a `search` tag.
+	searchColumns SearchColumns
+}
+
+// modelMetadataKey is the key of the metadata of a model type, which is the reflect.Type of a pointer to the model, the
+// name of the dialect that its statements are quoted for, and the table that its columns belong to.
+type modelMetadataKey struct {
+	t         reflect.Type
+	dialect   string
+	tableName string
+}
+
+// modelMetadataCache holds the *modelMetadata of each model type, dialect and table, keyed by modelMetadataKey.
+var modelMetadataCache sync.Map
+
+// getModelMetadata returns the metadata of the type of a model for a dialect, computing it if it is not already cached.
+// The table of the model is read from its value each time, since a TableName method may depend on it, ex. for a table
+// per tenant, and the metadata of each table is cached separately.
+func getModelMetadata(modelPtr interface{}, dialect Dialect) (*modelMetadata, error) {
+	key := modelMetadataKey{t: reflect.TypeOf(modelPtr), dialect: dialect.Name(), tableName: (&pop.Model{Value: modelPtr}).TableName()}
+	if cached, ok := modelMetadataCache.Load(key); ok {
+		return cached.(*modelMetadata), nil
+	}
+
+	v := reflect.ValueOf(modelPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("pointer to struct expected")
+	}
+
+	columns, err := getAllQueryableColumns(modelPtr, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	columnIndexes := make(map[string]int, len(columns))
+	for i, column := range columns {
+		columnIndexes[column.Name] = i
+	}
+
+	pathColumnNames, err := getAllJSONPathColumnNames(v.Elem().Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	searchColumns, err := getTaggedSearchColumns(modelPtr, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &modelMetadata{
+		columns:         columns,
+		columnIndexes:   columnIndexes,
+		pathColumnNames: pathColumnNames,
+		searchColumns:   searchColumns,
+	}
+
+	// If the metadata was computed concurrently, whichever was cached first is used.
+	cached, _ := modelMetadataCache.LoadOrStore(key, metadata)
+	return cached.(*modelMetadata), nil
+}
+
+// withCustomColumns returns the columns of the model, overridden or extended by the custom columns, in the order
+// described by CustomColumn.  A custom column that overrides a column of the model takes its position.  The result is a
+// new slice, which can be modified without affecting the metadata.
+func (m *modelMetadata) withCustomColumns(customColumns CustomColumns) []CustomColumn {
+	columns := make([]CustomColumn, len(m.columns), len(m.columns)+len(customColumns))
+	copy(columns, m.columns)
+
+	for _, customColumn := range customColumns {
+		if i, ok := m.columnIndexes[customColumn.Name]; ok {
+			columns[i] = customColumn
+			continue
+		}
+
+		// A later custom column overrides an earlier custom column of the same name.
+		overridden := false
+		for i := len(m.columns); i < len(columns); i++ {
+			if columns[i].Name == customColumn.Name {
+				columns[i] = customColumn
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			columns = append(columns, customColumn)
+		}
+	}
+
+	orderColumns(columns)
+	return columns
+}
+
+// InvalidateModelMetadata removes the cached metadata of the type of a model, so that it is computed again the next time
+// the model is used.
+func InvalidateModelMetadata(modelPtr interface{}) {
+	t := reflect.TypeOf(modelPtr)
+	modelMetadataCache.Range(func(key, _ interface{}) bool {
+		if key.(modelMetadataKey).t == t {
+			modelMetadataCache.Delete(key)
+		}
+		return true
+	})
+}
+
+// ClearModelMetadata removes the cached metadata of all models.
+func ClearModelMetadata() {
+	modelMetadataCache.Range(func(key, _ interface{}) bool {
+		modelMetadataCache.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,141 @@
+package scope_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/alphaflow/scope"
+)
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_Cached() {
+	filters, err := scope.GetAllFilterColumns(context.Background(), &TestObject{})
+	ss.NoError(err)
+
+	// Modifying the returned columns does not modify the cached columns.
+	for i := range filters {
+		filters[i].Statement = "NULL"
+	}
+
+	filters, err = scope.GetAllFilterColumns(context.Background(), &TestObject{})
+	ss.NoError(err)
+
+	filtersMap := make(map[string]scope.CustomColumn, len(filters))
+	for _, col := range filters {
+		filtersMap[col.Name] = col
+	}
+	ss.Len(filtersMap, 7)
+	ss.Equal("objects.id", filtersMap["id"].Statement)
+
+	scope.InvalidateModelMetadata(&TestObject{})
+
+	filters, err = scope.GetAllFilterColumns(context.Background(), &TestObject{})
+	ss.NoError(err)
+	ss.Len(filters, 7)
+
+	scope.ClearModelMetadata()
+
+	sorts, err := scope.GetAllSortColumns(context.Background(), &TestObject{})
+	ss.NoError(err)
+	ss.Len(sorts, 7)
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_CachedOverride() {
+	for i := 0; i < 2; i++ {
+		filters, err := scope.GetAllFilterColumns(context.Background(), &TestObjectWithOverride{})
+		ss.NoError(err)
+		ss.Len(filters, 2)
+
+		for _, col := range filters {
+			if col.Name == "object_id" {
+				ss.Equal(`NULL`, col.Statement)
+			}
+		}
+	}
+}
+
+// TestTenantObject is stored in a table per tenant.
+type TestTenantObject struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	Tenant string    `json:"-" db:"-"`
+}
+
+func (t TestTenantObject) TableName() string {
+	return fmt.Sprintf("%v_objects", t.Tenant)
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_CachedTableName() {
+	for _, tenant := range []string{"first", "second", "first"} {
+		filters, err := scope.GetAllFilterColumns(context.Background(), &TestTenantObject{Tenant: tenant})
+		ss.NoError(err)
+		ss.Len(filters, 1)
+		ss.Equal(fmt.Sprintf("%v_objects.id", tenant), filters[0].Statement)
+	}
+}
+
+func (ss *ScopesSuite) TestGetAllFilterColumns_Concurrent() {
+	scope.ClearModelMetadata()
+
+	var wg sync.WaitGroup
+	results := make([][]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = scope.GetAllFilterColumnNames(context.Background(), &TestObject{})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		ss.ElementsMatch(results[0], result)
+		ss.Len(result, 7)
+	}
+}
+
+func BenchmarkGetAllFilterColumns(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := scope.GetAllFilterColumns(context.Background(), &TestDatedObject{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetAllFilterColumns_Custom(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := scope.GetAllFilterColumns(context.Background(), &TestObject{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetAllSortColumns(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := scope.GetAllSortColumns(context.Background(), &TestObject{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkForFiltersFromParams(b *testing.B) {
+	params := url.Values{
+		"filter_columns": {"id|num"},
+		"filter_types":   {"nn|gt"},
+		"filter_values":  {"|1"},
+		"filter_logic":   {"and"},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := scope.ForFiltersFromParams(context.Background(), TestDatedObject{}, params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE dated_objects;
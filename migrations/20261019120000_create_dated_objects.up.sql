@@ -0,0 +1,6 @@
+CREATE TABLE dated_objects
+(
+    id          UUID PRIMARY KEY,
+    num         NUMERIC,
+    occurred_at TIMESTAMP
+);
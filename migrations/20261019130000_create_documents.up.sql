@@ -0,0 +1,6 @@
+CREATE TABLE documents
+(
+    id    UUID PRIMARY KEY,
+    title TEXT,
+    body  TEXT
+);
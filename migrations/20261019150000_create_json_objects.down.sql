@@ -0,0 +1 @@
+DROP TABLE json_objects;
@@ -0,0 +1,5 @@
+CREATE TABLE json_objects
+(
+    id       UUID PRIMARY KEY,
+    metadata JSONB
+);
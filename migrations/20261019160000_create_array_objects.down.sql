@@ -0,0 +1 @@
+DROP TABLE array_objects;
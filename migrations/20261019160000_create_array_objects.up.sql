@@ -0,0 +1,6 @@
+CREATE TABLE array_objects
+(
+    id          UUID PRIMARY KEY,
+    tags        TEXT[],
+    related_ids UUID[]
+);
@@ -0,0 +1,13 @@
+CREATE TABLE houses
+(
+    id   UUID PRIMARY KEY,
+    name TEXT
+);
+
+CREATE TABLE rooms
+(
+    id       UUID PRIMARY KEY,
+    house_id UUID REFERENCES houses (id),
+    name     TEXT,
+    area     NUMERIC
+);
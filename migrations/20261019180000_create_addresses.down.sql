@@ -0,0 +1,3 @@
+ALTER TABLE houses DROP COLUMN address_id;
+DROP TABLE addresses;
+DROP TABLE countries;
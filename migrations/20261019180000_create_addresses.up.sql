@@ -0,0 +1,14 @@
+CREATE TABLE countries
+(
+    id   UUID PRIMARY KEY,
+    name TEXT
+);
+
+CREATE TABLE addresses
+(
+    id         UUID PRIMARY KEY,
+    city       TEXT,
+    country_id UUID REFERENCES countries (id)
+);
+
+ALTER TABLE houses ADD COLUMN address_id UUID REFERENCES addresses (id);
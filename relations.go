@@ -0,0 +1,129 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gobuffalo/pop/v5"
+)
+
+// Quantifiers that can be used within ForFiltersFromParams for the columns of a relation.
+var relationQuantifiers = map[string]string{
+	"ANY":  "ANY",
+	"NONE": "NONE",
+	"ALL":  "ALL",
+}
+
+// Relation represents a has-many relation of a model, ex. the rooms of a house:
+//
+//	Relation{
+//	  Name:       "rooms",
+//	  ModelPtr:   &Room{},
+//	  JoinClause: "rooms.house_id = houses.id",
+//	}
+//
+// The filter columns of the related model can be used as filter columns of the model, prefixed by the name of the
+// relation, ex. `rooms.area`.  The name of the relation itself is a filter column of the number of related rows.
+type Relation struct {
+	Name       string
+	ModelPtr   interface{}
+	JoinClause string
+}
+
+type Relations []Relation
+
+// RelationFilterable is implemented by models with has-many relations that can be filtered on.
+type RelationFilterable interface {
+	GetRelations(ctx context.Context) Relations
+}
+
+// relationFilterColumn is a filter column of a relation.  Statement is evaluated for each related row, unless IsCount
+// is set, in which case Statement is the number of related rows.
+type relationFilterColumn struct {
+	Statement  string
+	ResultType reflect.Type
+	IsCount    bool
+	from       string
+	joinClause string
+	joins      []Join
+}
+
+// getRelationFilterColumn returns the filter column `name` of one of the relations of a model.  If `name` is not a
+// column of a relation, nil is returned.
+func getRelationFilterColumn(ctx context.Context, model interface{}, name string) (*relationFilterColumn, error) {
+	relationFilterable, ok := model.(RelationFilterable)
+	if !ok {
+		return nil, nil
+	}
+
+	for _, relation := range relationFilterable.GetRelations(ctx) {
+		tableName := (&pop.Model{Value: relation.ModelPtr}).TableName()
+
+		// Counts are cast, since SQLite compares the text of args to expressions without a type as text.
+		if name == relation.Name {
+			dialect := DialectFromContext(ctx)
+			return &relationFilterColumn{
+				Statement:  dialect.Cast(fmt.Sprintf("(SELECT COUNT(*) FROM %v WHERE %v)", fromTable(dialect, tableName), relation.JoinClause), "BIGINT"),
+				ResultType: reflect.TypeOf(0),
+				IsCount:    true,
+			}, nil
+		}
+
+		if !strings.HasPrefix(name, relation.Name+".") {
+			continue
+		}
+
+		filterColumns, err := GetAllFilterColumns(ctx, relation.ModelPtr)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, filterColumn := range filterColumns {
+			if name == fmt.Sprintf("%v.%v", relation.Name, filterColumn.Name) {
+				return &relationFilterColumn{
+					Statement:  filterColumn.Statement,
+					ResultType: filterColumn.ResultType,
+					from:       fromTable(DialectFromContext(ctx), tableName),
+					joinClause: relation.JoinClause,
+					joins:      filterColumn.Joins,
+				}, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// quantify returns the SQL placed before and the SQL placed after a filter clause on this column, which together
+// wrap the clause in an EXISTS subquery of the related rows, so that the model matches if any, none or all of its
+// related rows match the clause.  A model without related rows matches all clauses.  It returns a ParamError if the
+// quantifier is unknown.
+func (c relationFilterColumn) quantify(quantifier string) (string, string, error) {
+	if quantifier == "" {
+		quantifier = "ANY"
+	}
+
+	q, ok := relationQuantifiers[strings.ToUpper(quantifier)]
+	if !ok {
+		return "", "", newParamError(ParamErrorCodeInvalidType, "", quantifier, "invalid filter quantifier: %v", quantifier)
+	}
+
+	// The joins of the related columns are joined within the subquery.
+	from := c.from
+	for _, join := range c.joins {
+		from = fmt.Sprintf("%v %v", from, join)
+	}
+
+	from = fmt.Sprintf("SELECT 1 FROM %v WHERE (%v)", from, c.joinClause)
+	switch q {
+	case "NONE":
+		return fmt.Sprintf("NOT EXISTS (%v AND ", from), ")", nil
+	case "ALL":
+		// Rows where the clause is null do not match it, so they are found by IS NOT TRUE rather than NOT.
+		return fmt.Sprintf("NOT EXISTS (%v AND (", from), ") IS NOT TRUE)", nil
+	default:
+		return fmt.Sprintf("EXISTS (%v AND ", from), ")", nil
+	}
+}
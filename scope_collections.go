@@ -12,6 +12,7 @@ import (
 type Collection struct {
 	tx     *pop.Connection
 	scopes []pop.ScopeFunc
+	config *Config
 }
 
 func NewCollection(tx ...*pop.Connection) *Collection {
@@ -25,6 +26,14 @@ func NewCollection(tx ...*pop.Connection) *Collection {
 	return sc
 }
 
+// WithConfig attaches a config to the collection, which the functions passed the collection use unless their context
+// has a config, see ConfigFromContext.
+func (sc *Collection) WithConfig(config *Config) *Collection {
+	sc.config = config
+
+	return sc
+}
+
 func (sc *Collection) Get() []pop.ScopeFunc {
 	return sc.scopes
 }
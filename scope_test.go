@@ -3,7 +3,11 @@ package scope_test
 import (
 	"testing"
 
+	"github.com/gobuffalo/envy"
+	"github.com/gobuffalo/pop/v5"
 	"github.com/gobuffalo/suite/v3"
+
+	"github.com/alphaflow/scope"
 )
 
 type ScopesSuite struct {
@@ -13,9 +17,44 @@ type ScopesSuite struct {
 func Test_ScopesSuite(t *testing.T) {
 	model := suite.NewModel()
 
+	// The database is SQLite if TEST_DATABASE_DIALECT is `sqlite`, and otherwise the postgres database of database.yml.
+	if envy.Get("TEST_DATABASE_DIALECT", "postgres") == "sqlite" {
+		model.DB = newSQLiteConnection()
+	}
+
 	ss := &ScopesSuite{
 		Model: model,
 	}
 
 	suite.Run(t, ss)
 }
+
+// dialect returns the dialect of the database of the suite.
+func (ss *ScopesSuite) dialect() scope.Dialect {
+	if ss.DB.Dialect.Name() == "sqlite3" {
+		return scope.SQLiteDialect
+	}
+
+	return scope.PostgresDialect
+}
+
+// skipUnlessSupports skips a test unless the database of the suite supports all of the features, ex. a filter type.
+func (ss *ScopesSuite) skipUnlessSupports(features ...string) {
+	for _, feature := range features {
+		if !ss.dialect().Supports(feature) {
+			ss.T().Skipf("%v is not supported by %v", feature, ss.dialect().Name())
+		}
+	}
+}
+
+// dryRunDB returns a connection of a dialect that is only used to generate SQL, ex. with ToSQL.
+func (ss *ScopesSuite) dryRunDB(dialect string) *pop.Connection {
+	conn, err := pop.NewConnection(&pop.ConnectionDetails{
+		Dialect:  dialect,
+		Database: "scope_test",
+		Host:     "127.0.0.1",
+		User:     "root",
+	})
+	ss.NoError(err)
+	return conn
+}
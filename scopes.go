@@ -6,6 +6,7 @@ import (
 	"github.com/gobuffalo/nulls"
 	"github.com/gobuffalo/pop/v5"
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 )
 
 type IDSet map[uuid.UUID]bool
@@ -59,35 +60,79 @@ func ForLimit(limit int) pop.ScopeFunc {
 	}
 }
 
-func ForID(id string) pop.ScopeFunc {
-	return func(q *pop.Query) *pop.Query {
-		if len(id) > 0 {
-			return q.Where("id = ?", uuid.Must(uuid.FromString(id)))
-		}
-
-		return q.Where("1 = 0")
+// ParseID parses an ID, ex. from a URL.  Scopes of string IDs such as ForID don't return errors, so IDs from requests
+// should be parsed first to reject invalid IDs, and then scoped by their UUID, ex. with ForUuidID, or be scoped with
+// ForValidID, which returns an error for an invalid ID.
+func ParseID(id string) (uuid.UUID, error) {
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		return uuid.Nil, errors.Wrapf(err, "invalid id: %v", id)
 	}
+
+	return uid, nil
 }
 
-func ForIDWithTableName(id string, tablename string) pop.ScopeFunc {
-	return func(q *pop.Query) *pop.Query {
-		if len(id) > 0 {
-			return q.Where(fmt.Sprintf("%s.id = ?", tablename), uuid.Must(uuid.FromString(id)))
+// forParsedID returns the scope of a parsed ID.  An empty ID matches no rows, as an empty IDSet does.  No row has an
+// invalid ID, so it matches no rows, or all rows if `not` is set, since scopes cannot return errors.  See forValidID to
+// reject invalid IDs, ex. before an update or a delete.
+func forParsedID(id string, not bool, scopeForUuidID func(uid uuid.UUID) pop.ScopeFunc) pop.ScopeFunc {
+	uid, err := ParseID(id)
+	if err != nil {
+		return func(q *pop.Query) *pop.Query {
+			if not && len(id) > 0 {
+				return q
+			}
+
+			return q.Where("1 = 0")
 		}
+	}
 
-		return q.Where("1 = 0")
+	return scopeForUuidID(uid)
+}
+
+// forValidID returns the scope of a parsed ID, or a ParamError if the ID is empty or invalid.
+func forValidID(id string, scopeForUuidID func(uid uuid.UUID) pop.ScopeFunc) (pop.ScopeFunc, error) {
+	uid, err := ParseID(id)
+	if err != nil {
+		return nil, newParamError(ParamErrorCodeInvalidValue, "id", id, "invalid id: %v", id)
 	}
+
+	return scopeForUuidID(uid), nil
+}
+
+// ForID scopes a query to the row with an ID.  An empty or invalid ID matches no rows, see ForValidID.
+func ForID(id string) pop.ScopeFunc {
+	return forParsedID(id, false, ForUuidID)
+}
+
+func ForIDWithTableName(id string, tablename string) pop.ScopeFunc {
+	return forParsedID(id, false, func(uid uuid.UUID) pop.ScopeFunc {
+		return ForUuidIDWithTableName(uid, tablename)
+	})
 }
 
 func ForIDForModel(id string, model interface{}) pop.ScopeFunc {
-	return func(q *pop.Query) *pop.Query {
-		if len(id) > 0 {
-			tableNameAble := pop.Model{Value: model}
-			return q.Where(fmt.Sprintf("%s.id = ?", tableNameAble.TableName()), uuid.Must(uuid.FromString(id)))
-		}
+	return forParsedID(id, false, func(uid uuid.UUID) pop.ScopeFunc {
+		return ForUuidIDForModel(uid, model)
+	})
+}
 
-		return q.Where("1 = 0")
-	}
+// ForValidID scopes a query to the row with an ID, or returns a ParamError if the ID is empty or invalid, ex. an ID
+// from a URL that should be rejected with a 400.
+func ForValidID(id string) (pop.ScopeFunc, error) {
+	return forValidID(id, ForUuidID)
+}
+
+func ForValidIDWithTableName(id string, tablename string) (pop.ScopeFunc, error) {
+	return forValidID(id, func(uid uuid.UUID) pop.ScopeFunc {
+		return ForUuidIDWithTableName(uid, tablename)
+	})
+}
+
+func ForValidIDForModel(id string, model interface{}) (pop.ScopeFunc, error) {
+	return forValidID(id, func(uid uuid.UUID) pop.ScopeFunc {
+		return ForUuidIDForModel(uid, model)
+	})
 }
 
 func ForIDs(ids []uuid.UUID) pop.ScopeFunc {
@@ -111,7 +156,7 @@ func ForIDsWithTableName(ids []uuid.UUID, tablename string) pop.ScopeFunc {
 func ForIDSetWithTableName(idSet IDSet, tablename string) pop.ScopeFunc {
 	return func(q *pop.Query) *pop.Query {
 		if len(idSet) > 0 {
-			return q.Where(fmt.Sprintf("%s.id in (?)", tablename), idSet.Keys())
+			return q.Where(fmt.Sprintf("%s in (?)", quoteColumn(getDialect(q.Connection), tablename, "id")), idSet.Keys())
 		}
 
 		return q.Where("1 = 0")
@@ -126,42 +171,46 @@ func ForIDSetForModel(idSet IDSet, model interface{}) pop.ScopeFunc {
 	return func(q *pop.Query) *pop.Query {
 		if len(idSet) > 0 {
 			tableNameAble := pop.Model{Value: model}
-			return q.Where(fmt.Sprintf("%s.id in (?)", tableNameAble.TableName()), idSet.Keys())
+			return q.Where(fmt.Sprintf("%s in (?)", quoteColumn(getDialect(q.Connection), tableNameAble.TableName(), "id")), idSet.Keys())
 		}
 
 		return q.Where("1 = 0")
 	}
 }
 
+// ForNotID scopes a query to the rows without an ID.  An empty ID matches no rows, and an invalid ID matches all rows,
+// see ForNotValidID.
 func ForNotID(id string) pop.ScopeFunc {
-	return func(q *pop.Query) *pop.Query {
-		if len(id) > 0 {
-			return q.Where("id != ?", uuid.Must(uuid.FromString(id)))
-		}
-
-		return q.Where("1 = 0")
-	}
+	return forParsedID(id, true, ForNotUuidID)
 }
 
 func ForNotIDWithTableName(id string, tablename string) pop.ScopeFunc {
-	return func(q *pop.Query) *pop.Query {
-		if len(id) > 0 {
-			return q.Where(fmt.Sprintf("%s.id != ?", tablename), uuid.Must(uuid.FromString(id)))
-		}
-
-		return q.Where("1 = 0")
-	}
+	return forParsedID(id, true, func(uid uuid.UUID) pop.ScopeFunc {
+		return ForNotUuidIDWithTableName(uid, tablename)
+	})
 }
 
 func ForNotIDForNotModel(id string, model interface{}) pop.ScopeFunc {
-	return func(q *pop.Query) *pop.Query {
-		if len(id) > 0 {
-			tableNameAble := pop.Model{Value: model}
-			return q.Where(fmt.Sprintf("%s.id != ?", tableNameAble.TableName()), uuid.Must(uuid.FromString(id)))
-		}
+	return forParsedID(id, true, func(uid uuid.UUID) pop.ScopeFunc {
+		return ForNotUuidIDForModel(uid, model)
+	})
+}
 
-		return q.Where("1 = 0")
-	}
+// ForNotValidID scopes a query to the rows without an ID, or returns a ParamError if the ID is empty or invalid.
+func ForNotValidID(id string) (pop.ScopeFunc, error) {
+	return forValidID(id, ForNotUuidID)
+}
+
+func ForNotValidIDWithTableName(id string, tablename string) (pop.ScopeFunc, error) {
+	return forValidID(id, func(uid uuid.UUID) pop.ScopeFunc {
+		return ForNotUuidIDWithTableName(uid, tablename)
+	})
+}
+
+func ForNotValidIDForModel(id string, model interface{}) (pop.ScopeFunc, error) {
+	return forValidID(id, func(uid uuid.UUID) pop.ScopeFunc {
+		return ForNotUuidIDForModel(uid, model)
+	})
 }
 
 func ForNotIDs(ids []uuid.UUID) pop.ScopeFunc {
@@ -185,7 +234,7 @@ func ForNotIDsWithTableName(ids []uuid.UUID, tablename string) pop.ScopeFunc {
 func ForNotIDSetWithTableName(idSet IDSet, tablename string) pop.ScopeFunc {
 	return func(q *pop.Query) *pop.Query {
 		if len(idSet) > 0 {
-			return q.Where(fmt.Sprintf("%s.id not in (?)", tablename), idSet.Keys())
+			return q.Where(fmt.Sprintf("%s not in (?)", quoteColumn(getDialect(q.Connection), tablename, "id")), idSet.Keys())
 		}
 
 		return q.Where("1 = 0")
@@ -200,7 +249,7 @@ func ForNotIDSetForModel(idSet IDSet, model interface{}) pop.ScopeFunc {
 	return func(q *pop.Query) *pop.Query {
 		if len(idSet) > 0 {
 			tableNameAble := pop.Model{Value: model}
-			return q.Where(fmt.Sprintf("%s.id not in (?)", tableNameAble.TableName()), idSet.Keys())
+			return q.Where(fmt.Sprintf("%s not in (?)", quoteColumn(getDialect(q.Connection), tableNameAble.TableName(), "id")), idSet.Keys())
 		}
 
 		return q.Where("1 = 0")
@@ -219,6 +268,26 @@ func ForNotUuidID(uid uuid.UUID) pop.ScopeFunc {
 	}
 }
 
+func ForUuidIDWithTableName(uid uuid.UUID, tablename string) pop.ScopeFunc {
+	return func(q *pop.Query) *pop.Query {
+		return q.Where(fmt.Sprintf("%s = ?", quoteColumn(getDialect(q.Connection), tablename, "id")), uid)
+	}
+}
+
+func ForUuidIDForModel(uid uuid.UUID, model interface{}) pop.ScopeFunc {
+	return ForUuidIDWithTableName(uid, (&pop.Model{Value: model}).TableName())
+}
+
+func ForNotUuidIDWithTableName(uid uuid.UUID, tablename string) pop.ScopeFunc {
+	return func(q *pop.Query) *pop.Query {
+		return q.Where(fmt.Sprintf("%s != ?", quoteColumn(getDialect(q.Connection), tablename, "id")), uid)
+	}
+}
+
+func ForNotUuidIDForModel(uid uuid.UUID, model interface{}) pop.ScopeFunc {
+	return ForNotUuidIDWithTableName(uid, (&pop.Model{Value: model}).TableName())
+}
+
 func ForNullsUuidID(uid nulls.UUID) pop.ScopeFunc {
 	return func(q *pop.Query) *pop.Query {
 		if uid.Valid {
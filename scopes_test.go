@@ -1,7 +1,9 @@
 package scope_test
 
 import (
+	"github.com/gobuffalo/pop/v5"
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 
 	"github.com/alphaflow/scope"
 )
@@ -39,3 +41,78 @@ func (ss *ScopesSuite) TestNewIdSet_Keys() {
 
 	ss.ElementsMatch([]uuid.UUID{a, b, c}, keys)
 }
+
+func (ss *ScopesSuite) TestParseID() {
+	id := uuid.Must(uuid.NewV4())
+
+	parsed, err := scope.ParseID(id.String())
+	ss.NoError(err)
+	ss.Equal(id, parsed)
+
+	_, err = scope.ParseID("not-an-id")
+	ss.Error(err)
+
+	_, err = scope.ParseID("")
+	ss.Error(err)
+}
+
+func (ss *ScopesSuite) TestForID_Invalid() {
+	for _, s := range []pop.ScopeFunc{
+		scope.ForID("not-an-id"),
+		scope.ForIDWithTableName("not-an-id", "objects"),
+		scope.ForIDForModel("not-an-id", TestObject{}),
+		scope.ForID(""),
+		scope.ForIDWithTableName("", "objects"),
+		scope.ForIDForModel("", TestObject{}),
+		scope.ForNotID(""),
+		scope.ForNotIDWithTableName("", "objects"),
+		scope.ForNotIDForNotModel("", TestObject{}),
+	} {
+		query, args := ss.dryRunDB("postgres").Q().Scope(s).ToSQL(&pop.Model{Value: TestObject{}})
+		ss.Contains(query, "WHERE 1 = 0")
+		ss.Empty(args)
+	}
+
+	// No row has an invalid ID, so all rows match.
+	for _, s := range []pop.ScopeFunc{
+		scope.ForNotID("not-an-id"),
+		scope.ForNotIDWithTableName("not-an-id", "objects"),
+		scope.ForNotIDForNotModel("not-an-id", TestObject{}),
+	} {
+		query, args := ss.dryRunDB("postgres").Q().Scope(s).ToSQL(&pop.Model{Value: TestObject{}})
+		ss.NotContains(query, "WHERE")
+		ss.Empty(args)
+	}
+
+	id := uuid.Must(uuid.NewV4())
+	query, args := ss.dryRunDB("postgres").Q().Scope(scope.ForIDForModel(id.String(), TestObject{})).ToSQL(&pop.Model{Value: TestObject{}})
+	ss.Contains(query, "WHERE objects.id = $1")
+	ss.Equal([]interface{}{id}, args)
+}
+
+func (ss *ScopesSuite) TestForValidID() {
+	for _, newScope := range []func(id string) (pop.ScopeFunc, error){
+		scope.ForValidID,
+		scope.ForNotValidID,
+		func(id string) (pop.ScopeFunc, error) { return scope.ForValidIDWithTableName(id, "objects") },
+		func(id string) (pop.ScopeFunc, error) { return scope.ForNotValidIDWithTableName(id, "objects") },
+		func(id string) (pop.ScopeFunc, error) { return scope.ForValidIDForModel(id, TestObject{}) },
+		func(id string) (pop.ScopeFunc, error) { return scope.ForNotValidIDForModel(id, TestObject{}) },
+	} {
+		for _, id := range []string{"", "not-an-id"} {
+			_, err := newScope(id)
+			var paramErr *scope.ParamError
+			ss.True(errors.As(err, &paramErr))
+			ss.Equal(scope.ParamErrorCodeInvalidValue, paramErr.Code)
+			ss.Equal(id, paramErr.Value)
+		}
+	}
+
+	id := uuid.Must(uuid.NewV4())
+	s, err := scope.ForNotValidIDForModel(id.String(), TestObject{})
+	ss.NoError(err)
+
+	query, args := ss.dryRunDB("postgres").Q().Scope(s).ToSQL(&pop.Model{Value: TestObject{}})
+	ss.Contains(query, "WHERE objects.id != $1")
+	ss.Equal([]interface{}{id}, args)
+}
@@ -0,0 +1,209 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/alphaflow/scope"
+)
+
+type TestDocument struct {
+	ID    uuid.UUID `json:"id" db:"id"`
+	Title string    `json:"title" db:"title" search:"A"`
+	Body  string    `json:"body" db:"body" search:"B"`
+}
+
+func (t TestDocument) TableName() string {
+	return "documents"
+}
+
+type TestSearchableObject struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	Number float64   `json:"num" db:"num"`
+}
+
+func (t TestSearchableObject) TableName() string {
+	return "objects"
+}
+
+func (t TestSearchableObject) GetSearchColumns(ctx context.Context) scope.SearchColumns {
+	return scope.SearchColumns{{Statement: "objects.num", Weight: "a"}}
+}
+
+type TestInvalidSearchableObject struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	Number float64   `json:"num" db:"num" search:"E"`
+}
+
+func (t TestInvalidSearchableObject) TableName() string {
+	return "objects"
+}
+
+func (ss *ScopesSuite) createDocuments() (*TestDocument, *TestDocument) {
+	report := &TestDocument{ID: uuid.Must(uuid.NewV4()), Title: "Quarterly report", Body: "Revenue grew"}
+	err := ss.DB.Create(report)
+	ss.NoError(err)
+
+	summary := &TestDocument{ID: uuid.Must(uuid.NewV4()), Title: "Annual summary", Body: "The quarterly revenue is reported"}
+	err = ss.DB.Create(summary)
+	ss.NoError(err)
+
+	return report, summary
+}
+
+func (ss *ScopesSuite) TestGetAllSearchColumns() {
+	searchColumns, err := scope.GetAllSearchColumns(context.Background(), &TestDocument{})
+	ss.NoError(err)
+	ss.Equal(scope.SearchColumns{{Statement: "documents.title", Weight: "A"}, {Statement: "documents.body", Weight: "B"}}, searchColumns)
+
+	searchColumns, err = scope.GetAllSearchColumns(context.Background(), &TestSearchableObject{})
+	ss.NoError(err)
+	ss.Equal(scope.SearchColumns{{Statement: "objects.num", Weight: "a"}}, searchColumns)
+
+	searchColumns, err = scope.GetAllSearchColumns(context.Background(), &TestObject{})
+	ss.NoError(err)
+	ss.Empty(searchColumns)
+}
+
+func (ss *ScopesSuite) TestForSearchFromParams() {
+	ss.skipUnlessSupports(scope.FeatureSearch)
+
+	report, summary := ss.createDocuments()
+
+	testCases := []struct {
+		Search   string
+		Expected []uuid.UUID
+	}{
+		{Search: "", Expected: []uuid.UUID{report.ID, summary.ID}},
+		{Search: "quarterly", Expected: []uuid.UUID{report.ID, summary.ID}},
+		{Search: "reports", Expected: []uuid.UUID{report.ID, summary.ID}},
+		{Search: "annual", Expected: []uuid.UUID{summary.ID}},
+		{Search: "quarterly -annual", Expected: []uuid.UUID{report.ID}},
+		{Search: `"revenue grew"`, Expected: []uuid.UUID{report.ID}},
+		{Search: "profit", Expected: []uuid.UUID{}},
+	}
+
+	for _, testCase := range testCases {
+		s, err := scope.ForSearchFromParams(context.Background(), TestDocument{}, url.Values{"q": {testCase.Search}})
+		ss.NoError(err)
+
+		documents := []TestDocument{}
+		err = ss.DB.Scope(s).All(&documents)
+		ss.NoError(err)
+
+		ids := make([]uuid.UUID, len(documents))
+		for i := range documents {
+			ids[i] = documents[i].ID
+		}
+
+		ss.ElementsMatch(testCase.Expected, ids, testCase.Search)
+	}
+}
+
+func (ss *ScopesSuite) TestForSearchFromParams_withFilters() {
+	ss.skipUnlessSupports(scope.FeatureSearch)
+
+	_, summary := ss.createDocuments()
+
+	params := url.Values{
+		"q":              {"quarterly"},
+		"filter_columns": {"title"},
+		"filter_types":   {"ILK"},
+		"filter_values":  {"%summary%"},
+	}
+
+	searchScope, err := scope.ForSearchFromParams(context.Background(), TestDocument{}, params)
+	ss.NoError(err)
+
+	filterScope, err := scope.ForFiltersFromParams(context.Background(), TestDocument{}, params)
+	ss.NoError(err)
+
+	sc := scope.NewCollection(ss.DB)
+	sc.Push(searchScope, filterScope)
+
+	documents := []TestDocument{}
+	err = ss.DB.Scope(sc.Flatten()).All(&documents)
+	ss.NoError(err)
+	ss.Len(documents, 1)
+	ss.Equal(summary.ID, documents[0].ID)
+}
+
+func (ss *ScopesSuite) TestForSortFromParams_relevance() {
+	ss.skipUnlessSupports(scope.FeatureSearch)
+
+	report, summary := ss.createDocuments()
+
+	// Matches in the title are more relevant than matches in the body.
+	params := url.Values{
+		"q":               {"quarterly"},
+		"sort_columns":    {"relevance"},
+		"sort_directions": {"DESC"},
+	}
+
+	searchScope, err := scope.ForSearchFromParams(context.Background(), TestDocument{}, params)
+	ss.NoError(err)
+
+	sortScope, err := scope.ForSortFromParams(context.Background(), TestDocument{}, params)
+	ss.NoError(err)
+
+	sc := scope.NewCollection(ss.DB)
+	sc.Push(searchScope, sortScope)
+
+	documents := []TestDocument{}
+	err = ss.DB.Scope(sc.Flatten()).All(&documents)
+	ss.NoError(err)
+	ss.Len(documents, 2)
+	ss.Equal(report.ID, documents[0].ID)
+	ss.Equal(summary.ID, documents[1].ID)
+
+	// Searches are quoted safely within the sort.
+	params["q"] = []string{`quarterly's \ report`}
+	sortScope, err = scope.ForSortFromParams(context.Background(), TestDocument{}, params)
+	ss.NoError(err)
+
+	documents = []TestDocument{}
+	err = ss.DB.Scope(searchScope).Scope(sortScope).All(&documents)
+	ss.NoError(err)
+	ss.Len(documents, 2)
+	ss.Equal(report.ID, documents[0].ID)
+}
+
+func (ss *ScopesSuite) TestForSearchFromParams_customColumns() {
+	ss.skipUnlessSupports(scope.FeatureSearch)
+
+	testObject := &TestSearchableObject{ID: uuid.Must(uuid.NewV4()), Number: 12}
+	err := ss.DB.Create(testObject)
+	ss.NoError(err)
+
+	testObject2 := &TestSearchableObject{ID: uuid.Must(uuid.NewV4()), Number: 13}
+	err = ss.DB.Create(testObject2)
+	ss.NoError(err)
+
+	s, err := scope.ForSearchFromParams(context.Background(), TestSearchableObject{}, url.Values{"q": {"12"}})
+	ss.NoError(err)
+
+	testObjects := []TestSearchableObject{}
+	err = ss.DB.Scope(s).All(&testObjects)
+	ss.NoError(err)
+	ss.Len(testObjects, 1)
+	ss.Equal(testObject.ID, testObjects[0].ID)
+}
+
+func (ss *ScopesSuite) TestForSearchFromParams_invalid() {
+	_, err := scope.ForSearchFromParams(context.Background(), TestObject{}, url.Values{"q": {"test"}})
+	ss.Error(err)
+
+	_, err = scope.ForSearchFromParams(context.Background(), TestInvalidSearchableObject{}, url.Values{"q": {"test"}})
+	ss.Error(err)
+
+	_, err = scope.ForSearchFromParams(context.Background(), &TestDocument{}, url.Values{"q": {"test"}})
+	ss.Error(err)
+
+	_, err = scope.ForSortFromParams(context.Background(), TestDocument{}, url.Values{"sort_columns": {"relevance"}, "sort_directions": {"DESC"}})
+	ss.Error(err)
+
+	_, err = scope.ForSortFromParams(context.Background(), TestObject{}, url.Values{"q": {"test"}, "sort_columns": {"relevance"}, "sort_directions": {"DESC"}})
+	ss.Error(err)
+}
@@ -0,0 +1,89 @@
+package scope
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope/util"
+)
+
+// FilterSimilarityThreshold is the minimum pg_trgm similarity, between 0 and 1, of a value matched by the SIM filter
+// type, which is the default FilterSimilarityThreshold of NewConfig.  It can be overridden per request by the
+// `filter_similarity_threshold` param.
+var FilterSimilarityThreshold = 0.3
+
+// ErrSimilarityUnavailable is returned by CheckSimilarityExtension, and by the queries of similarity filters and sorts,
+// when the pg_trgm extension is not installed, see SimilarityError.
+var ErrSimilarityUnavailable = errors.New("similarity filters and sorts require the pg_trgm extension")
+
+// trigramSimilarityThreshold is the default pg_trgm.similarity_threshold, which is the threshold of the `%` operator.
+const trigramSimilarityThreshold = 0.3
+
+// undefinedFunctionSQLState is the SQLSTATE of errors for functions and operators that do not exist.
+const undefinedFunctionSQLState = "42883"
+
+// similarityExtensionQueryResult is the result of the query for the pg_trgm extension in CheckSimilarityExtension.
+type similarityExtensionQueryResult struct {
+	Installed bool `db:"installed"`
+}
+
+// CheckSimilarityExtension returns ErrSimilarityUnavailable if the pg_trgm extension is not installed.  The SIM filter
+// type and sort direction use functions of pg_trgm, so queries using them fail without the extension.  Endpoints that
+// allow these params should check for the extension, ex. when the app starts, in order to return a meaningful error.
+func CheckSimilarityExtension(tx *pop.Connection) error {
+	result := &similarityExtensionQueryResult{}
+	err := tx.RawQuery("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = ?) AS installed", "pg_trgm").First(result)
+	if err != nil {
+		return err
+	}
+
+	if !result.Installed {
+		return ErrSimilarityUnavailable
+	}
+
+	return nil
+}
+
+// getFilterSimilarityThreshold gets the similarity threshold of the SIM filter type.  The parameter
+// filter_similarity_threshold can be used to override the FilterSimilarityThreshold of the config of the context.
+func getFilterSimilarityThreshold(ctx context.Context, params buffalo.ParamValues) (float64, error) {
+	if util.IsBlank(params.Get("filter_similarity_threshold")) {
+		return ConfigFromContext(ctx).FilterSimilarityThreshold, nil
+	}
+
+	threshold, err := strconv.ParseFloat(params.Get("filter_similarity_threshold"), 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		return 0, newParamError(ParamErrorCodeInvalidValue, "filter_similarity_threshold", params.Get("filter_similarity_threshold"), "invalid filter similarity threshold: %v", params.Get("filter_similarity_threshold"))
+	}
+
+	return threshold, nil
+}
+
+// similarityFilterUsesOperator returns true if SIM filters with a threshold use the `%` operator, which can use a
+// trigram index.  The operator only matches values above the pg_trgm.similarity_threshold, so lower thresholds can only
+// be checked by the similarity function.
+func similarityFilterUsesOperator(threshold float64) bool {
+	return threshold >= trigramSimilarityThreshold
+}
+
+// SimilarityError returns ErrSimilarityUnavailable if err is a query error because the functions or operators of
+// pg_trgm do not exist, and returns err otherwise.  The queries of aggregations, filter facets and filter options already
+// return ErrSimilarityUnavailable, and lists using the SIM filter type or sort direction can pass their query errors
+// through SimilarityError in order to return a meaningful error without checking for the extension.
+func SimilarityError(err error) error {
+	var stateErr interface{ SQLState() string }
+	if err == nil || !errors.As(err, &stateErr) || stateErr.SQLState() != undefinedFunctionSQLState {
+		return err
+	}
+
+	if message := err.Error(); strings.Contains(message, "similarity(") || strings.Contains(message, " % ") {
+		return ErrSimilarityUnavailable
+	}
+
+	return err
+}
@@ -0,0 +1,16 @@
+//go:build !sqlite
+// +build !sqlite
+
+package scope_test
+
+import (
+	"log"
+
+	"github.com/gobuffalo/pop/v5"
+)
+
+// newSQLiteConnection exits, since pop only includes its SQLite dialect with the sqlite build tag.
+func newSQLiteConnection() *pop.Connection {
+	log.Fatal("SQLite test runs need the sqlite build tag, ex. TEST_DATABASE_DIALECT=sqlite go test -tags sqlite .")
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build sqlite
+// +build sqlite
+
+package scope_test
+
+import (
+	"io/ioutil"
+	"log"
+
+	"github.com/gobuffalo/pop/v5"
+)
+
+// newSQLiteConnection returns a connection to an in-memory SQLite database with the tables of testdata/sqlite.sql.
+func newSQLiteConnection() *pop.Connection {
+	schema, err := ioutil.ReadFile("testdata/sqlite.sql")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conn, err := pop.NewConnection(&pop.ConnectionDetails{
+		Dialect: "sqlite3",
+		URL:     "sqlite3://file::memory:?cache=shared&_fk=true",
+		Pool:    1,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := conn.Open(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := conn.RawQuery(string(schema)).Exec(); err != nil {
+		log.Fatal(err)
+	}
+
+	return conn
+}
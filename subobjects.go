@@ -0,0 +1,161 @@
+package scope
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+
+	"github.com/alphaflow/scope/util"
+)
+
+// Join is a LEFT JOIN of the table `Table` as `Alias`, on the condition `On`.
+type Join struct {
+	Table string
+	Alias string
+	On    string
+}
+
+// String returns the SQL of the join.
+func (j Join) String() string {
+	return fmt.Sprintf("LEFT JOIN %v AS %v ON %v", j.Table, j.Alias, j.On)
+}
+
+// Subobject represents a to-one relation of a model, which is joined where the column `Column` of the model equals the
+// column `References` of the subobject.  Subobjects may have Subobjects of their own, to any depth.
+type Subobject struct {
+	Name       string
+	ModelPtr   interface{}
+	Column     string
+	References string
+	Subobjects []Subobject
+}
+
+// GenerateCustomColumnsForSubobjects is a utility in order to automatically create custom filter columns for the
+// subobjects of a model, and the subobjects of those subobjects.  Unlike GenerateCustomColumnsForSubobject, the columns
+// are selected from LEFT JOINs rather than a subquery per column, and each subobject is only joined once no matter how
+// many of its columns are used.  Identifiers are quoted as needed for postgres, see QuoteIdentifier.
+//
+// For example, assume you have a table Houses, where each house has an address_id field pointing to an Addresses
+// table, and each address has a country_id field pointing to a Countries table.  In order to make houses sortable by
+// the columns of both tables, you will need to implement 'CustomSortable' and return the following:
+//
+//	GenerateCustomColumnsForSubobjects(&House{}, Subobject{
+//	  Name:       "address",
+//	  ModelPtr:   &Address{},
+//	  Column:     "address_id",
+//	  References: "id",
+//	  Subobjects: []Subobject{{Name: "country", ModelPtr: &Country{}, Column: "country_id", References: "id"}},
+//	})
+//
+// Which will return a list of CustomColumns derived from the fields of the `&Address{}` and `&Country{}` models.
+//
+//	CustomColumns{
+//	  {
+//	    Name:       "address.city",
+//	    Statement:  "address.city",
+//	    ResultType: reflect.TypeOf(""),
+//	    Joins:      []Join{{Table: "addresses", Alias: "address", On: "address.id = houses.address_id"}},
+//	  },
+//	  {
+//	    Name:       "address.country.name",
+//	    Statement:  "address__country.name",
+//	    ResultType: reflect.TypeOf(""),
+//	    Joins:      []Join{
+//	      {Table: "addresses", Alias: "address", On: "address.id = houses.address_id"},
+//	      {Table: "countries", Alias: "address__country", On: "address__country.id = address.country_id"},
+//	    },
+//	  },
+//	  ...
+//	}
+func GenerateCustomColumnsForSubobjects(modelPtr interface{}, subobjects ...Subobject) (CustomColumns, error) {
+	v := reflect.ValueOf(modelPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("pointer to struct expected")
+	}
+
+	tableName := (&pop.Model{Value: modelPtr}).TableName()
+	return generateCustomColumnsForSubobjects(tableName, "", nil, subobjects)
+}
+
+// generateCustomColumnsForSubobjects returns the custom columns of `subobjects`, which are joined to the parent
+// `parentAlias` by `parentJoins`.
+func generateCustomColumnsForSubobjects(parentAlias, parentName string, parentJoins []Join, subobjects []Subobject) (CustomColumns, error) {
+	customColumns := CustomColumns{}
+
+	for _, subobject := range subobjects {
+		v := reflect.ValueOf(subobject.ModelPtr)
+		if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+			return nil, errors.New("pointer to struct expected")
+		}
+
+		if util.IsBlank(subobject.Name) || strings.Contains(subobject.Name, ".") || util.IsBlank(subobject.Column) || util.IsBlank(subobject.References) {
+			return nil, errors.Errorf("invalid subobject: %v", subobject.Name)
+		}
+
+		name := subobject.Name
+		if parentName != "" {
+			name = fmt.Sprintf("%v.%v", parentName, subobject.Name)
+		}
+
+		// Each subobject is aliased by its path, so that the same table can be joined more than once.
+		alias := strings.ReplaceAll(name, ".", "__")
+
+		joins := make([]Join, len(parentJoins), len(parentJoins)+1)
+		copy(joins, parentJoins)
+		joins = append(joins, Join{
+			Table: QuoteIdentifier(PostgresDialect, (&pop.Model{Value: subobject.ModelPtr}).TableName()),
+			Alias: quoteIdentifierPart(PostgresDialect, alias),
+			On:    fmt.Sprintf("%v = %v", quoteColumn(PostgresDialect, alias, subobject.References), quoteColumn(PostgresDialect, parentAlias, subobject.Column)),
+		})
+
+		model := v.Elem().Interface()
+
+		fields, err := util.ModelFields(model)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range fields {
+			if field.JSONName == "" || field.DBName == "" {
+				continue
+			}
+
+			customColumns = append(customColumns, CustomColumn{
+				Name:       fmt.Sprintf("%v.%v", name, field.JSONName),
+				Statement:  quoteColumn(PostgresDialect, alias, field.DBName),
+				ResultType: field.Type,
+				Joins:      joins,
+			})
+		}
+
+		nestedColumns, err := generateCustomColumnsForSubobjects(alias, name, joins, subobject.Subobjects)
+		if err != nil {
+			return nil, err
+		}
+
+		customColumns = append(customColumns, nestedColumns...)
+	}
+
+	return customColumns, nil
+}
+
+// ForJoins joins a query to each of `joins`, unless the query is already joined to it.
+func ForJoins(joins ...Join) pop.ScopeFunc {
+	type __stub__ struct{}
+	return func(q *pop.Query) *pop.Query {
+		querySQL, _ := q.ToSQL(&pop.Model{Value: __stub__{}})
+		for _, join := range joins {
+			if strings.Contains(querySQL, join.String()) {
+				continue
+			}
+
+			q = q.LeftJoin(fmt.Sprintf("%v AS %v", join.Table, join.Alias), join.On)
+			querySQL = fmt.Sprintf("%v %v", querySQL, join)
+		}
+
+		return q
+	}
+}
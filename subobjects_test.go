@@ -0,0 +1,155 @@
+package scope_test
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+
+	"github.com/alphaflow/scope"
+)
+
+type TestAddress struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	City      string     `json:"city" db:"city"`
+	CountryID nulls.UUID `json:"country_id" db:"country_id"`
+}
+
+func (t TestAddress) TableName() string {
+	return "addresses"
+}
+
+type TestCountry struct {
+	ID   uuid.UUID `json:"id" db:"id"`
+	Name string    `json:"name" db:"name"`
+}
+
+func (t TestCountry) TableName() string {
+	return "countries"
+}
+
+var testHouseSubobjects = []scope.Subobject{{
+	Name:       "address",
+	ModelPtr:   &TestAddress{},
+	Column:     "address_id",
+	References: "id",
+	Subobjects: []scope.Subobject{{Name: "country", ModelPtr: &TestCountry{}, Column: "country_id", References: "id"}},
+}}
+
+func (t TestHouse) GetCustomFilters(ctx context.Context) scope.CustomColumns {
+	customColumns, _ := scope.GenerateCustomColumnsForSubobjects(&TestHouse{}, testHouseSubobjects...)
+	return customColumns
+}
+
+func (t TestHouse) GetCustomSorts(ctx context.Context) scope.CustomColumns {
+	customColumns, _ := scope.GenerateCustomColumnsForSubobjects(&TestHouse{}, testHouseSubobjects...)
+	return customColumns
+}
+
+func (ss *ScopesSuite) TestGenerateCustomColumnsForSubobjects() {
+	customColumns, err := scope.GenerateCustomColumnsForSubobjects(&TestHouse{}, testHouseSubobjects...)
+	ss.NoError(err)
+
+	addressJoin := scope.Join{Table: "addresses", Alias: "address", On: "address.id = houses.address_id"}
+	countryJoin := scope.Join{Table: "countries", Alias: "address__country", On: "address__country.id = address.country_id"}
+
+	ss.Equal(scope.CustomColumns{
+		{Name: "address.id", Statement: "address.id", ResultType: reflect.TypeOf(uuid.UUID{}), Joins: []scope.Join{addressJoin}},
+		{Name: "address.city", Statement: "address.city", ResultType: reflect.TypeOf(""), Joins: []scope.Join{addressJoin}},
+		{Name: "address.country_id", Statement: "address.country_id", ResultType: reflect.TypeOf(nulls.UUID{}), Joins: []scope.Join{addressJoin}},
+		{Name: "address.country.id", Statement: "address__country.id", ResultType: reflect.TypeOf(uuid.UUID{}), Joins: []scope.Join{addressJoin, countryJoin}},
+		{Name: "address.country.name", Statement: "address__country.name", ResultType: reflect.TypeOf(""), Joins: []scope.Join{addressJoin, countryJoin}},
+	}, customColumns)
+	ss.Equal("LEFT JOIN countries AS address__country ON address__country.id = address.country_id", countryJoin.String())
+
+	_, err = scope.GenerateCustomColumnsForSubobjects(&TestHouse{}, scope.Subobject{Name: "address.country", ModelPtr: &TestAddress{}, Column: "address_id", References: "id"})
+	ss.Error(err)
+
+	_, err = scope.GenerateCustomColumnsForSubobjects(&TestHouse{}, scope.Subobject{Name: "address", ModelPtr: TestAddress{}, Column: "address_id", References: "id"})
+	ss.Error(err)
+}
+
+func (ss *ScopesSuite) TestForJoins() {
+	params := url.Values{
+		"filter_columns":  {"address.city|address.country.name"},
+		"filter_types":    {"eq|eq"},
+		"filter_values":   {"Paris|France"},
+		"filter_logic":    {"and"},
+		"sort_columns":    {"address.city"},
+		"sort_directions": {"asc"},
+	}
+
+	filterScope, err := scope.ForFiltersFromParams(context.Background(), TestHouse{}, params)
+	ss.NoError(err)
+
+	sortScope, err := scope.ForSortFromParams(context.Background(), TestHouse{}, params)
+	ss.NoError(err)
+
+	// Each subobject is only joined once, no matter how many of its columns are used.
+	query, _ := ss.dryRunDB("postgres").Q().Scope(sortScope).Scope(filterScope).ToSQL(&pop.Model{Value: TestHouse{}})
+	ss.Equal(1, strings.Count(query, "LEFT JOIN addresses AS address ON address.id = houses.address_id"))
+	ss.Equal(1, strings.Count(query, "LEFT JOIN countries AS address__country ON address__country.id = address.country_id"))
+	ss.Less(strings.Index(query, "LEFT JOIN addresses"), strings.Index(query, "LEFT JOIN countries"))
+}
+
+func (ss *ScopesSuite) TestGenerateCustomColumnsForSubobjects_query() {
+	france := &TestCountry{ID: uuid.Must(uuid.NewV4()), Name: "France"}
+	err := ss.DB.Create(france)
+	ss.NoError(err)
+
+	paris := &TestAddress{ID: uuid.Must(uuid.NewV4()), City: "Paris", CountryID: nulls.NewUUID(france.ID)}
+	err = ss.DB.Create(paris)
+	ss.NoError(err)
+
+	lyon := &TestAddress{ID: uuid.Must(uuid.NewV4()), City: "Lyon", CountryID: nulls.NewUUID(france.ID)}
+	err = ss.DB.Create(lyon)
+	ss.NoError(err)
+
+	parisHouse := &TestHouse{ID: uuid.Must(uuid.NewV4()), Name: "paris", AddressID: nulls.NewUUID(paris.ID)}
+	err = ss.DB.Create(parisHouse)
+	ss.NoError(err)
+
+	lyonHouse := &TestHouse{ID: uuid.Must(uuid.NewV4()), Name: "lyon", AddressID: nulls.NewUUID(lyon.ID)}
+	err = ss.DB.Create(lyonHouse)
+	ss.NoError(err)
+
+	homeless := &TestHouse{ID: uuid.Must(uuid.NewV4()), Name: "homeless"}
+	err = ss.DB.Create(homeless)
+	ss.NoError(err)
+
+	params := url.Values{
+		"filter_columns":  {"address.country.name"},
+		"filter_types":    {"eq"},
+		"filter_values":   {"France"},
+		"sort_columns":    {"address.city"},
+		"sort_directions": {"asc"},
+	}
+
+	filterScope, err := scope.ForFiltersFromParams(context.Background(), TestHouse{}, params)
+	ss.NoError(err)
+
+	sortScope, err := scope.ForSortFromParams(context.Background(), TestHouse{}, params)
+	ss.NoError(err)
+
+	sc := scope.NewCollection(ss.DB)
+	sc.Push(filterScope, sortScope)
+
+	houses := []TestHouse{}
+	err = ss.DB.Scope(sc.Flatten()).All(&houses)
+	ss.NoError(err)
+	ss.Len(houses, 2)
+	ss.Equal(lyonHouse.ID, houses[0].ID)
+	ss.Equal(parisHouse.ID, houses[1].ID)
+
+	// Filter options of joined columns are restricted by scopes using the same joins.
+	filterScopes := scope.NewCollection(ss.DB)
+	filterScopes.Push(filterScope)
+
+	filterOptions, err := scope.GetFilterOptions(context.Background(), ss.DB, &[]TestHouse{}, "address.city", filterScopes)
+	ss.NoError(err)
+	ss.Equal([]interface{}{"Lyon", "Paris"}, filterOptions)
+}
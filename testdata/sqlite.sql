@@ -0,0 +1,65 @@
+-- The test tables of the migrations, with types that SQLite supports, for test runs with TEST_DATABASE_DIALECT=sqlite.
+-- Tables of postgres features, ex. arrays, are created so that the database can be cleared, but their tests are skipped.
+-- Foreign keys are left out, since the test setup clears the tables in any order.
+CREATE TABLE objects
+(
+    id          TEXT PRIMARY KEY,
+    db_null_id  TEXT,
+    num         NUMERIC,
+    not_in_json INT
+);
+
+CREATE TABLE dated_objects
+(
+    id          TEXT PRIMARY KEY,
+    num         NUMERIC,
+    occurred_at TIMESTAMP
+);
+
+CREATE TABLE documents
+(
+    id    TEXT PRIMARY KEY,
+    title TEXT,
+    body  TEXT
+);
+
+CREATE TABLE json_objects
+(
+    id       TEXT PRIMARY KEY,
+    metadata TEXT
+);
+
+CREATE TABLE array_objects
+(
+    id          TEXT PRIMARY KEY,
+    tags        TEXT,
+    related_ids TEXT
+);
+
+CREATE TABLE countries
+(
+    id   TEXT PRIMARY KEY,
+    name TEXT
+);
+
+CREATE TABLE addresses
+(
+    id         TEXT PRIMARY KEY,
+    city       TEXT,
+    country_id TEXT
+);
+
+CREATE TABLE houses
+(
+    id         TEXT PRIMARY KEY,
+    name       TEXT,
+    address_id TEXT
+);
+
+CREATE TABLE rooms
+(
+    id       TEXT PRIMARY KEY,
+    house_id TEXT,
+    name     TEXT,
+    area     NUMERIC
+);
@@ -0,0 +1,179 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ModelField is a field of a model.  The field is either a field of the model itself, or a field of a struct embedded
+// within the model.
+//
+// Name is the name of the field in go, and Index is its index sequence for reflect.Value.FieldByIndex.
+// JSONName is the name of the field in json, ex. `author.name` for the field `name` of a `gorm:"embedded"` field
+// `author`, and is blank if the field is not exposed by a json tag.
+// DBName is the name of the column of the field, including any `embeddedPrefix`, and is blank if the field does not
+// have a db column.
+type ModelField struct {
+	Name     string
+	JSONName string
+	DBName   string
+	Type     reflect.Type
+	Tag      reflect.StructTag
+	Index    []int
+}
+
+// modelField is a ModelField along with the depth of embedded structs it was found at.
+type modelField struct {
+	ModelField
+	depth int
+}
+
+// ModelFields returns all of the exported fields of a model, including the fields of embedded structs.  Embedded structs are handled the same way as encoding/json: the fields of an anonymous struct without a
+// json name are promoted to the model, and a promoted field is hidden by a field of the same name at a shallower depth.
+// Structs tagged `gorm:"embedded"` are nested within their json name, and their columns are prefixed by any
+// `gorm:"embeddedPrefix:..."`.
+//
+// An error is returned if two fields at the same depth have the same json name, since neither could be filtered on.
+func ModelFields(model interface{}) ([]ModelField, error) {
+	_, t := StructValueAndType(model)
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("struct expected")
+	}
+
+	fields := collectModelFields(t, nil, "", "", true, 0)
+
+	depths := make(map[string]int, len(fields))
+	for _, field := range fields {
+		if field.JSONName == "" {
+			continue
+		} else if depth, ok := depths[field.JSONName]; ok && depth == field.depth {
+			return nil, errors.Errorf("duplicate field: %v is used by more than one field of %v", field.JSONName, t.Name())
+		} else if !ok || field.depth < depth {
+			depths[field.JSONName] = field.depth
+		}
+	}
+
+	output := make([]ModelField, 0, len(fields))
+	for _, field := range fields {
+		if field.JSONName == "" || depths[field.JSONName] == field.depth {
+			output = append(output, field.ModelField)
+		}
+	}
+
+	return output, nil
+}
+
+// collectModelFields returns the fields of the struct type t, and of the structs embedded within it.  If the struct is
+// not exposed in json, none of its fields are either.
+func collectModelFields(t reflect.Type, index []int, jsonPrefix, dbPrefix string, exposed bool, depth int) []modelField {
+	fields := make([]modelField, 0, t.NumField())
+	for _, sf := range visibleStructFields(t, index) {
+		jsonTag, hasJSONTag := sf.Tag.Lookup("json")
+		jsonName := strings.Split(jsonTag, ",")[0]
+		fieldExposed := exposed && hasJSONTag && jsonName != "-"
+
+		fieldType := sf.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		// Structs embedded by gorm are nested within their json name.
+		gormSettings := parseGormTag(sf.Tag.Get("gorm"))
+		if _, embedded := gormSettings["EMBEDDED"]; (embedded || gormSettings["EMBEDDEDPREFIX"] != "") && fieldType.Kind() == reflect.Struct {
+			if jsonName == "" {
+				jsonName = sf.Name
+			}
+
+			// A struct embedded by gorm is exposed as an object even without a json tag.
+			nestedExposed := exposed && jsonName != "-"
+			nestedIndex := append([]int{}, sf.Index...)
+			fields = append(fields, collectModelFields(fieldType, nestedIndex, fmt.Sprintf("%v%v.", jsonPrefix, jsonName), dbPrefix+gormSettings["EMBEDDEDPREFIX"], nestedExposed, depth+1)...)
+			continue
+		}
+
+		if !fieldExposed {
+			jsonName = ""
+		} else if jsonName == "" {
+			jsonName = sf.Name
+		}
+
+		if jsonName != "" {
+			jsonName = jsonPrefix + jsonName
+		}
+
+		dbName := sf.Tag.Get("db")
+		if dbName == "-" {
+			dbName = ""
+		} else if dbName != "" {
+			dbName = dbPrefix + dbName
+		}
+
+		fields = append(fields, modelField{
+			ModelField: ModelField{
+				Name:     sf.Name,
+				JSONName: jsonName,
+				DBName:   dbName,
+				Type:     sf.Type,
+				Tag:      sf.Tag,
+				Index:    sf.Index,
+			},
+			depth: depth + len(sf.Index) - len(index) - 1,
+		})
+	}
+
+	return fields
+}
+
+// visibleStructFields returns the fields of the struct type t, replacing anonymous structs without a json name by
+// their own fields, as encoding/json does.  The Index of each field is its full index sequence, prefixed by `index`.
+func visibleStructFields(t reflect.Type, index []int) []reflect.StructField {
+	fields := make([]reflect.StructField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		sf.Index = append(append([]int{}, index...), i)
+
+		fieldType := sf.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		jsonName := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if sf.Anonymous && jsonName == "" && fieldType.Kind() == reflect.Struct {
+			fields = append(fields, visibleStructFields(fieldType, sf.Index)...)
+			continue
+		}
+
+		// Unexported fields are never exposed.
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		fields = append(fields, sf)
+	}
+
+	return fields
+}
+
+// parseGormTag returns the settings of a gorm tag, keyed by their upper case names, ex. `gorm:"embeddedPrefix:a_"`
+// returns {"EMBEDDEDPREFIX": "a_"}.
+func parseGormTag(tag string) map[string]string {
+	settings := make(map[string]string)
+	for _, setting := range strings.Split(tag, ";") {
+		if IsBlank(setting) {
+			continue
+		}
+
+		values := strings.SplitN(setting, ":", 2)
+		key := strings.ToUpper(strings.TrimSpace(values[0]))
+		if len(values) == 2 {
+			settings[key] = values[1]
+		} else {
+			settings[key] = ""
+		}
+	}
+
+	return settings
+}
@@ -16,9 +16,7 @@ func IsBlank(s string) bool {
 func LookupForStructFieldTag(model interface{}, field string, tagName string) (value string, ok bool) {
 	_, t := StructValueAndType(model)
 
-	for i := 0; i < t.NumField(); i++ {
-		sf := t.Field(i)
-
+	for _, sf := range visibleStructFields(t, nil) {
 		if sf.Name == field {
 			return sf.Tag.Lookup(tagName)
 		}
@@ -32,8 +30,7 @@ func ValuesForStructTag(model interface{}, tagName string) []string {
 	_, t := StructValueAndType(model)
 
 	output := make([]string, 0)
-	for i := 0; i < t.NumField(); i++ {
-		sf := t.Field(i)
+	for _, sf := range visibleStructFields(t, nil) {
 		value := strings.Split(sf.Tag.Get(tagName), ",")[0]
 
 		if value != "-" {
@@ -48,10 +45,9 @@ func ValuesForStructTag(model interface{}, tagName string) []string {
 // primary key in the json tag, unwinding custom json tag options.
 // See documentation at https://golang.org/pkg/encoding/json/#Marshal
 func FieldWithJsonTagValue(model interface{}, value string) (name string, ok bool) {
-	v, t := StructValueAndType(model)
+	_, t := StructValueAndType(model)
 
-	for i := 0; i < v.NumField(); i++ {
-		f := t.Field(i)
+	for _, f := range visibleStructFields(t, nil) {
 		if v, ok := f.Tag.Lookup("json"); ok {
 
 			primaryTag := strings.Split(v, ",")[0]
@@ -89,7 +85,7 @@ func InterfaceSlice(slice interface{}) []interface{} {
 	return ret
 }
 
-// GetFieldByName gets the value of a field on the given structPtr.
+// GetFieldByName gets the value of a field on the given structPtr, which may be promoted from an embedded struct.
 func GetFieldByName(structPtr interface{}, fieldName string) reflect.Value {
 	return reflect.ValueOf(structPtr).Elem().FieldByName(fieldName)
 }